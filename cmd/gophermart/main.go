@@ -1,7 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 
@@ -13,6 +18,8 @@ import (
 	"github.com/PrahaTurbo/gophermart/internal/storage"
 )
 
+const shutdownTimeout = time.Second * 30
+
 func main() {
 	c := config.Load()
 	log := logger.NewLogger()
@@ -23,13 +30,53 @@ func main() {
 	}
 	defer db.Close()
 
+	tiers, err := service.ParseTiers(c.Tiers)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to parse loyalty tiers")
+	}
+
 	storage := storage.NewStorage(db, log)
-	accrualClient := client.NewAccrualClient(c.AccrualSysAddr)
-	service := service.NewService(storage, accrualClient, log)
-	application := app.NewApp(c.JWTSecret, service, log)
+	accrualClient := client.NewAccrualClient(c.AccrualSysAddr, c.AccrualRateLimit)
+	service := service.NewService(storage, accrualClient, log, service.Config{
+		AccrualWorkers: c.AccrualWorkers,
+		AccrualMaxAge:  c.AccrualMaxAge,
+		HoldTTL:        c.HoldTTL,
+		PointsTTL:      c.PointsTTL,
+
+		TransferLimit:      c.TransferLimit,
+		TransferDailyLimit: c.TransferDailyLimit,
+		Tiers:              tiers,
+		ReferralBonus:      c.ReferralBonus,
+		ReferralLimit:      c.ReferralLimit,
+	})
+	application := app.NewApp(c.JWTSecret, c.AdminToken, service, log)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{
+		Addr:    c.RunAddr,
+		Handler: application.Router(),
+	}
+
+	go func() {
+		log.Info().Str("address", c.RunAddr).Msg("server is running")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msg("server failed")
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info().Msg("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("failed to shutdown server")
+	}
 
-	log.Info().Str("address", c.RunAddr).Msg("server is running")
-	if err := http.ListenAndServe(c.RunAddr, application.Router()); err != nil {
-		log.Fatal().Err(err)
+	if err := service.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("failed to drain accrual updater")
 	}
 }
@@ -3,13 +3,28 @@ package config
 import (
 	"flag"
 	"os"
+	"strconv"
+	"time"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
 )
 
 type Config struct {
-	RunAddr        string
-	DatabaseURI    string
-	AccrualSysAddr string
-	JWTSecret      string
+	RunAddr            string
+	DatabaseURI        string
+	AccrualSysAddr     string
+	AccrualWorkers     int
+	AccrualRateLimit   int
+	AccrualMaxAge      time.Duration
+	HoldTTL            time.Duration
+	PointsTTL          time.Duration
+	TransferLimit      models.Amount
+	TransferDailyLimit models.Amount
+	Tiers              string
+	ReferralBonus      models.Amount
+	ReferralLimit      int
+	JWTSecret          string
+	AdminToken         string
 }
 
 func Load() Config {
@@ -17,6 +32,21 @@ func Load() Config {
 	flag.StringVar(&c.RunAddr, "a", "localhost:8080", "server address in a form host:port")
 	flag.StringVar(&c.DatabaseURI, "d", "", "database address")
 	flag.StringVar(&c.AccrualSysAddr, "r", "http://localhost:8081", "accrual system address")
+	flag.IntVar(&c.AccrualWorkers, "w", 10, "number of accrual system workers")
+	flag.IntVar(&c.AccrualRateLimit, "l", 100, "max requests per second to accrual system, 0 for no limit")
+	flag.DurationVar(&c.AccrualMaxAge, "m", time.Hour*24*7, "how long to wait for accrual before an order becomes unresolved")
+	flag.DurationVar(&c.HoldTTL, "t", time.Minute*15, "how long held points stay reserved before the hold expires")
+	flag.DurationVar(&c.PointsTTL, "e", time.Hour*24*365, "how long earned points stay valid, 0 to never expire")
+
+	c.TransferLimit, c.TransferDailyLimit = 100000, 500000
+	flag.Var(&c.TransferLimit, "transfer-limit", "max points in a single transfer, 0 for no limit")
+	flag.Var(&c.TransferDailyLimit, "transfer-daily-limit", "max points a user can transfer within 24 hours, 0 for no limit")
+
+	flag.StringVar(&c.Tiers, "tiers", "BRONZE:0:1,SILVER:1000:1.1,GOLD:5000:1.25", "loyalty tiers as name:threshold:multiplier, empty to disable")
+
+	c.ReferralBonus = 10000
+	flag.Var(&c.ReferralBonus, "referral-bonus", "points both referrer and referee get for the referee's first processed order")
+	flag.IntVar(&c.ReferralLimit, "referral-limit", 20, "max referrals a user is rewarded for, 0 for no limit")
 
 	flag.Parse()
 
@@ -38,6 +68,71 @@ func (c *Config) loadEnvVars() {
 		c.AccrualSysAddr = envAccrualSysAddr
 	}
 
+	if envAccrualWorkers := os.Getenv("ACCRUAL_WORKERS"); envAccrualWorkers != "" {
+		if workers, err := strconv.Atoi(envAccrualWorkers); err == nil {
+			c.AccrualWorkers = workers
+		}
+	}
+
+	if envAccrualRateLimit := os.Getenv("ACCRUAL_RATE_LIMIT"); envAccrualRateLimit != "" {
+		if rateLimit, err := strconv.Atoi(envAccrualRateLimit); err == nil {
+			c.AccrualRateLimit = rateLimit
+		}
+	}
+
+	if envAccrualMaxAge := os.Getenv("ACCRUAL_MAX_AGE"); envAccrualMaxAge != "" {
+		if maxAge, err := time.ParseDuration(envAccrualMaxAge); err == nil {
+			c.AccrualMaxAge = maxAge
+		}
+	}
+
+	if envHoldTTL := os.Getenv("HOLD_TTL"); envHoldTTL != "" {
+		if holdTTL, err := time.ParseDuration(envHoldTTL); err == nil {
+			c.HoldTTL = holdTTL
+		}
+	}
+
+	if envPointsTTL := os.Getenv("POINTS_TTL"); envPointsTTL != "" {
+		if pointsTTL, err := time.ParseDuration(envPointsTTL); err == nil {
+			c.PointsTTL = pointsTTL
+		}
+	}
+
+	if envTransferLimit := os.Getenv("TRANSFER_LIMIT"); envTransferLimit != "" {
+		var limit models.Amount
+		if err := limit.Set(envTransferLimit); err == nil {
+			c.TransferLimit = limit
+		}
+	}
+
+	if envTransferDailyLimit := os.Getenv("TRANSFER_DAILY_LIMIT"); envTransferDailyLimit != "" {
+		var limit models.Amount
+		if err := limit.Set(envTransferDailyLimit); err == nil {
+			c.TransferDailyLimit = limit
+		}
+	}
+
+	if envReferralBonus := os.Getenv("REFERRAL_BONUS"); envReferralBonus != "" {
+		var bonus models.Amount
+		if err := bonus.Set(envReferralBonus); err == nil {
+			c.ReferralBonus = bonus
+		}
+	}
+
+	if envReferralLimit := os.Getenv("REFERRAL_LIMIT"); envReferralLimit != "" {
+		if limit, err := strconv.Atoi(envReferralLimit); err == nil {
+			c.ReferralLimit = limit
+		}
+	}
+
+	if envTiers, ok := os.LookupEnv("TIERS"); ok {
+		c.Tiers = envTiers
+	}
+
+	if envAdminToken := os.Getenv("ADMIN_TOKEN"); envAdminToken != "" {
+		c.AdminToken = envAdminToken
+	}
+
 	if envJWTSecret := os.Getenv("JWT_SECRET"); envJWTSecret != "" {
 		c.JWTSecret = envJWTSecret
 	} else {
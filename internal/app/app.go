@@ -12,15 +12,17 @@ type App interface {
 }
 
 type application struct {
-	jwtSecret string
-	service   service.Service
-	log       logger.Logger
+	jwtSecret  string
+	adminToken string
+	service    service.Service
+	log        logger.Logger
 }
 
-func NewApp(jwtSecret string, srv service.Service, logger logger.Logger) App {
+func NewApp(jwtSecret, adminToken string, srv service.Service, logger logger.Logger) App {
 	return &application{
-		jwtSecret: jwtSecret,
-		service:   srv,
-		log:       logger,
+		jwtSecret:  jwtSecret,
+		adminToken: adminToken,
+		service:    srv,
+		log:        logger,
 	}
 }
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+)
+
+func (a *application) createCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	var campaignReq models.CampaignRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&campaignReq); err != nil {
+		for _, field := range []string{"bonus", "min_accrual"} {
+			if ok, fieldErr := amountFieldError(field, err); ok {
+				a.writeValidationError(w, http.StatusBadRequest, fieldErr)
+				return
+			}
+		}
+
+		a.log.Error().Err(err).Msg("cannot unmarshal response")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := campaignReq.Validate(); err != nil {
+		a.writeValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	campaign, err := a.service.CreateCampaign(r.Context(), campaignReq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(campaign); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *application) getCampaignsHandler(w http.ResponseWriter, r *http.Request) {
+	campaigns, err := a.service.GetCampaigns(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if len(campaigns) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(campaigns); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
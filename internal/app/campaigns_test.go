@@ -0,0 +1,110 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+)
+
+func Test_application_createCampaignHandler(t *testing.T) {
+	app := application{
+		adminToken: "admin-token",
+		log:        logger.NewLogger(),
+	}
+
+	type want struct {
+		statusCode  int
+		contentType string
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		prepare func(s *mocks.MockService)
+		want    want
+	}{
+		{
+			name: "should create campaign",
+			body: `{"name": "double points", "multiplier": 2, "starts_at": "2023-09-16T00:00:00Z", "ends_at": "2023-09-18T00:00:00Z"}`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					CreateCampaign(gomock.Any(), gomock.Any()).
+					Return(&models.CampaignResponse{ID: 1, Name: "double points", Multiplier: "2"}, nil)
+			},
+			want: want{
+				statusCode:  http.StatusCreated,
+				contentType: "application/json",
+			},
+		},
+		{
+			name:    "should return 400 if campaign gives no bonus",
+			body:    `{"name": "nothing", "starts_at": "2023-09-16T00:00:00Z", "ends_at": "2023-09-18T00:00:00Z"}`,
+			prepare: func(s *mocks.MockService) {},
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "application/json",
+			},
+		},
+		{
+			name:    "should return 400 if campaign ends before it starts",
+			body:    `{"name": "first order", "bonus": 100, "first_order_only": true, "starts_at": "2023-09-18T00:00:00Z", "ends_at": "2023-09-16T00:00:00Z"}`,
+			prepare: func(s *mocks.MockService) {},
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "application/json",
+			},
+		},
+		{
+			name:    "should return 400 if bonus has too many fractional digits",
+			body:    `{"name": "first order", "bonus": 1.005, "starts_at": "2023-09-16T00:00:00Z", "ends_at": "2023-09-18T00:00:00Z"}`,
+			prepare: func(s *mocks.MockService) {},
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "application/json",
+			},
+		},
+		{
+			name: "should return 500 when internal error",
+			body: `{"name": "first order", "bonus": 100, "starts_at": "2023-09-16T00:00:00Z", "ends_at": "2023-09-18T00:00:00Z"}`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					CreateCampaign(gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("internal error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+
+			tt.prepare(service)
+			app.service = service
+
+			request := httptest.NewRequest(http.MethodPost, "/api/admin/campaigns", bytes.NewBufferString(tt.body))
+			request.Header.Set("Authorization", "Bearer admin-token")
+
+			w := httptest.NewRecorder()
+			app.Router().ServeHTTP(w, request)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+
+			if tt.want.contentType != "" {
+				assert.Equal(t, tt.want.contentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
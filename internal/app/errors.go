@@ -0,0 +1,38 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/validator"
+)
+
+// writeValidationError responds with status and the field errors of err.
+func (a *application) writeValidationError(w http.ResponseWriter, status int, err error) {
+	a.log.Info().Err(err).Msg("request validation failed")
+
+	var fieldErrs validator.Errors
+	errors.As(err, &fieldErrs)
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(models.ErrorResponse{Errors: fieldErrs}); err != nil {
+		a.log.Error().Err(err).Msg("failed to encode validation error")
+	}
+}
+
+// amountFieldError reports whether err is a decoding error caused by a
+// malformed amount and returns it as an error of field.
+func amountFieldError(field string, err error) (bool, error) {
+	if errors.Is(err, models.ErrAmountPrecision) ||
+		errors.Is(err, models.ErrAmountNotNumber) ||
+		errors.Is(err, models.ErrAmountRange) {
+		return true, validator.Errors{{Field: field, Message: err.Error()}}
+	}
+
+	return false, nil
+}
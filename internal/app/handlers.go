@@ -4,13 +4,16 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"strings"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/pkg/errors"
 
 	"github.com/PrahaTurbo/gophermart/internal/auth"
 	"github.com/PrahaTurbo/gophermart/internal/models"
 	"github.com/PrahaTurbo/gophermart/internal/service"
 	"github.com/PrahaTurbo/gophermart/internal/storage"
+	"github.com/PrahaTurbo/gophermart/internal/validator"
 )
 
 func (a *application) registerUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -22,9 +25,8 @@ func (a *application) registerUserHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if user.Password == "" || user.Login == "" {
-		a.log.Error().Msg("password or login is empty")
-		w.WriteHeader(http.StatusBadRequest)
+	if err := user.Validate(); err != nil {
+		a.writeValidationError(w, http.StatusBadRequest, err)
 		return
 	}
 
@@ -34,6 +36,11 @@ func (a *application) registerUserHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if errors.Is(err, service.ErrReferralCodeNotFound) {
+		a.writeValidationError(w, http.StatusBadRequest, validator.Errors{{Field: "referral_code", Message: err.Error()}})
+		return
+	}
+
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -59,9 +66,8 @@ func (a *application) loginUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if user.Password == "" || user.Login == "" {
-		a.log.Error().Msg("password or login is empty")
-		w.WriteHeader(http.StatusBadRequest)
+	if err := user.Validate(); err != nil {
+		a.writeValidationError(w, http.StatusBadRequest, err)
 		return
 	}
 
@@ -89,7 +95,13 @@ func (a *application) processOrderHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = a.service.ProcessOrder(r.Context(), string(body))
+	req := models.OrderRequest{Number: strings.TrimSpace(string(body))}
+	if err := req.Validate(); err != nil {
+		a.writeValidationError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	err = a.service.ProcessOrder(r.Context(), req.Number)
 
 	if errors.Is(err, service.ErrInvalidOrderID) {
 		w.WriteHeader(http.StatusUnprocessableEntity)
@@ -115,7 +127,18 @@ func (a *application) processOrderHandler(w http.ResponseWriter, r *http.Request
 }
 
 func (a *application) getOrdersHandler(w http.ResponseWriter, r *http.Request) {
-	orders, err := a.service.GetUserOrders(r.Context())
+	req, err := parseListRequest(r, orderStatuses)
+	if err != nil {
+		a.writeValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	orders, next, err := a.service.GetUserOrders(r.Context(), req)
+	if errors.Is(err, service.ErrInvalidCursor) {
+		a.writeValidationError(w, http.StatusBadRequest, validator.Errors{{Field: "cursor", Message: "is invalid"}})
+		return
+	}
+
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -126,6 +149,10 @@ func (a *application) getOrdersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if next != "" {
+		w.Header().Set(nextCursorHeader, next)
+	}
+
 	w.Header().Set("content-type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
@@ -136,6 +163,67 @@ func (a *application) getOrdersHandler(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// getOrderHandler returns one of the user's orders by its number.
+func (a *application) getOrderHandler(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "number")
+
+	order, err := a.service.GetUserOrder(r.Context(), orderID)
+	if errors.Is(err, service.ErrOrderNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *application) getPendingOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	orders, err := a.service.GetPendingOrders(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if len(orders) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(orders); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *application) getReferralHandler(w http.ResponseWriter, r *http.Request) {
+	referral, err := a.service.GetReferral(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(referral); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 func (a *application) getBalanceHandler(w http.ResponseWriter, r *http.Request) {
 	balance, err := a.service.GetBalance(r.Context())
 	if err != nil {
@@ -152,15 +240,59 @@ func (a *application) getBalanceHandler(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+func (a *application) getBalanceHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := parseBalanceHistoryRequest(r)
+	if err != nil {
+		a.writeValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	history, err := a.service.GetBalanceHistory(r.Context(), req.From, req.To)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if len(history) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 func (a *application) withdrawHandler(w http.ResponseWriter, r *http.Request) {
 	var withdrawReq models.WithdrawRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&withdrawReq); err != nil {
+		if ok, fieldErr := amountFieldError("sum", err); ok {
+			a.writeValidationError(w, http.StatusBadRequest, fieldErr)
+			return
+		}
+
 		a.log.Error().Err(err).Msg("cannot unmarshal response")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
+	if err := withdrawReq.Validate(); err != nil {
+		status := http.StatusBadRequest
+
+		var fieldErrs validator.Errors
+		if errors.As(err, &fieldErrs) && fieldErrs.Has("order") {
+			status = http.StatusUnprocessableEntity
+		}
+
+		a.writeValidationError(w, status, err)
+		return
+	}
+
 	err := a.service.Withdraw(r.Context(), withdrawReq)
 
 	if errors.Is(err, service.ErrInvalidOrderID) {
@@ -168,11 +300,70 @@ func (a *application) withdrawHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if errors.Is(err, service.ErrInvalidWithdrawSum) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if errors.Is(err, service.ErrBalanceNotEnough) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		return
+	}
+
+	if errors.Is(err, service.ErrWithdrawalOrderUsed) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *application) transferHandler(w http.ResponseWriter, r *http.Request) {
+	var transferReq models.TransferRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&transferReq); err != nil {
+		if ok, fieldErr := amountFieldError("sum", err); ok {
+			a.writeValidationError(w, http.StatusBadRequest, fieldErr)
+			return
+		}
+
+		a.log.Error().Err(err).Msg("cannot unmarshal request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := transferReq.Validate(); err != nil {
+		a.writeValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err := a.service.Transfer(r.Context(), transferReq)
+
+	if errors.Is(err, service.ErrInvalidWithdrawSum) || errors.Is(err, service.ErrTransferToSelf) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if errors.Is(err, service.ErrRecipientNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
 	if errors.Is(err, service.ErrBalanceNotEnough) {
 		w.WriteHeader(http.StatusPaymentRequired)
 		return
 	}
 
+	if errors.Is(err, service.ErrTransferLimitExceeded) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -182,7 +373,18 @@ func (a *application) withdrawHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *application) getWithdrawalsHandler(w http.ResponseWriter, r *http.Request) {
-	withdrawals, err := a.service.GetUserWithdrawals(r.Context())
+	req, err := parseListRequest(r, withdrawalStatuses)
+	if err != nil {
+		a.writeValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	withdrawals, next, err := a.service.GetUserWithdrawals(r.Context(), req)
+	if errors.Is(err, service.ErrInvalidCursor) {
+		a.writeValidationError(w, http.StatusBadRequest, validator.Errors{{Field: "cursor", Message: "is invalid"}})
+		return
+	}
+
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -193,6 +395,10 @@ func (a *application) getWithdrawalsHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if next != "" {
+		w.Header().Set(nextCursorHeader, next)
+	}
+
 	w.Header().Set("content-type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
@@ -202,3 +408,34 @@ func (a *application) getWithdrawalsHandler(w http.ResponseWriter, r *http.Reque
 	}
 
 }
+
+func (a *application) reverseWithdrawalHandler(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "order")
+
+	withdrawal, err := a.service.ReverseWithdrawal(r.Context(), orderID)
+	if errors.Is(err, service.ErrWithdrawalNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(withdrawal); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// metricsHandler serves the accrual updater gauges in the expvar format.
+func (a *application) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	io.WriteString(w, service.Metrics.String())
+}
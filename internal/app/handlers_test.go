@@ -6,7 +6,9 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
@@ -67,6 +69,22 @@ func Test_application_registerUserHandler(t *testing.T) {
 				statusCode: http.StatusConflict,
 			},
 		},
+		{
+			name:        "should return 400 if referral code doesn't exist",
+			requestBody: `{"login": "test", "password": "test_password", "referral_code": "ABCDEFGH"}`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					CreateUser(gomock.Any(), models.UserRequest{
+						Login:        "test",
+						Password:     "test_password",
+						ReferralCode: "ABCDEFGH",
+					}).
+					Return(0, service.ErrReferralCodeNotFound)
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
 		{
 			name:        "should return 500 if can't save user",
 			requestBody: `{"login": "test", "password": "test_password"}`,
@@ -255,11 +273,7 @@ func Test_application_processOrderHandler(t *testing.T) {
 		{
 			name:        "should return 422 when order id doesn't match luhn algorithm",
 			requestBody: "1234567890",
-			prepare: func(s *mocks.MockService) {
-				s.EXPECT().
-					ProcessOrder(gomock.Any(), "1234567890").
-					Return(service.ErrInvalidOrderID)
-			},
+			prepare:     func(s *mocks.MockService) {},
 			want: want{
 				statusCode: http.StatusUnprocessableEntity,
 			},
@@ -288,6 +302,14 @@ func Test_application_processOrderHandler(t *testing.T) {
 				statusCode: http.StatusOK,
 			},
 		},
+		{
+			name:        "should return 422 when order id contains non-digits",
+			requestBody: "1234567890a",
+			prepare:     func(s *mocks.MockService) {},
+			want: want{
+				statusCode: http.StatusUnprocessableEntity,
+			},
+		},
 		{
 			name:        "should return 500 when internal server error",
 			requestBody: "12345678903",
@@ -330,10 +352,12 @@ func Test_application_getOrdersHandler(t *testing.T) {
 	type want struct {
 		statusCode  int
 		contentType string
+		nextCursor  string
 	}
 
 	tests := []struct {
 		name    string
+		query   string
 		prepare func(s *mocks.MockService)
 		want    want
 	}{
@@ -341,15 +365,15 @@ func Test_application_getOrdersHandler(t *testing.T) {
 			name: "should successfully return list of orders",
 			prepare: func(s *mocks.MockService) {
 				s.EXPECT().
-					GetUserOrders(gomock.Any()).
+					GetUserOrders(gomock.Any(), gomock.Any()).
 					Return([]models.OrderResponse{
 						{
 							ID:         "123",
-							Accrual:    23,
+							Accrual:    2300,
 							Status:     "status",
 							UploadedAt: "date",
 						},
-					}, nil)
+					}, "", nil)
 			},
 			want: want{
 				statusCode:  http.StatusOK,
@@ -360,7 +384,224 @@ func Test_application_getOrdersHandler(t *testing.T) {
 			name: "should return 204 if list of orders is empty",
 			prepare: func(s *mocks.MockService) {
 				s.EXPECT().
-					GetUserOrders(gomock.Any()).
+					GetUserOrders(gomock.Any(), gomock.Any()).
+					Return([]models.OrderResponse{}, "", nil)
+			},
+			want: want{
+				statusCode: http.StatusNoContent,
+			},
+		},
+		{
+			name:  "should pass list parameters and return next cursor",
+			query: "?limit=2&cursor=abc&status=new,processed&sort=desc",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					GetUserOrders(gomock.Any(), models.ListRequest{
+						Limit:    2,
+						Cursor:   "abc",
+						Statuses: []string{"NEW", "PROCESSED"},
+						Desc:     true,
+					}).
+					Return([]models.OrderResponse{{ID: "123"}, {ID: "456"}}, "next", nil)
+			},
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+				nextCursor:  "next",
+			},
+		},
+		{
+			name:  "should page by default limit if only cursor is given",
+			query: "?cursor=abc",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					GetUserOrders(gomock.Any(), models.ListRequest{Limit: defaultListLimit, Cursor: "abc"}).
+					Return([]models.OrderResponse{{ID: "123"}}, "", nil)
+			},
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+			},
+		},
+		{
+			name:    "should return 400 if list parameters are invalid",
+			query:   "?limit=0&status=DONE&sort=up",
+			prepare: func(s *mocks.MockService) {},
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "application/json",
+			},
+		},
+		{
+			name:  "should return 400 if cursor is invalid",
+			query: "?cursor=bad",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					GetUserOrders(gomock.Any(), gomock.Any()).
+					Return(nil, "", service.ErrInvalidCursor)
+			},
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "application/json",
+			},
+		},
+		{
+			name: "should return 500 when internal error",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					GetUserOrders(gomock.Any(), gomock.Any()).
+					Return(nil, "", errors.New("internal error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+
+			tt.prepare(service)
+			app.service = service
+
+			request := httptest.NewRequest(http.MethodGet, "/api/user/orders"+tt.query, nil)
+
+			w := httptest.NewRecorder()
+			app.getOrdersHandler(w, request)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+
+			if tt.want.contentType != "" {
+				assert.Equal(t, tt.want.contentType, w.Header().Get("Content-Type"))
+			}
+
+			assert.Equal(t, tt.want.nextCursor, w.Header().Get(nextCursorHeader))
+		})
+	}
+}
+
+func Test_application_getOrderHandler(t *testing.T) {
+	app := application{
+		log: logger.NewLogger(),
+	}
+
+	type want struct {
+		statusCode  int
+		contentType string
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *mocks.MockService)
+		want    want
+	}{
+		{
+			name: "should successfully return order",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					GetUserOrder(gomock.Any(), "12345678903").
+					Return(&models.OrderResponse{
+						ID:         "12345678903",
+						Accrual:    2300,
+						Status:     "PROCESSED",
+						UploadedAt: "date",
+						CreditedAt: "date",
+					}, nil)
+			},
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+			},
+		},
+		{
+			name: "should return 404 when order is not found",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					GetUserOrder(gomock.Any(), "12345678903").
+					Return(nil, service.ErrOrderNotFound)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "should return 500 when internal error",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					GetUserOrder(gomock.Any(), "12345678903").
+					Return(nil, errors.New("internal error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+
+			tt.prepare(service)
+			app.service = service
+
+			r := chi.NewRouter()
+			r.Get("/api/user/orders/{number}", app.getOrderHandler)
+
+			request := httptest.NewRequest(http.MethodGet, "/api/user/orders/12345678903", nil)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, request)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+
+			if tt.want.contentType != "" {
+				assert.Equal(t, tt.want.contentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func Test_application_getPendingOrdersHandler(t *testing.T) {
+	app := application{
+		log: logger.NewLogger(),
+	}
+
+	type want struct {
+		statusCode  int
+		contentType string
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *mocks.MockService)
+		want    want
+	}{
+		{
+			name: "should successfully return list of pending orders",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					GetPendingOrders(gomock.Any()).
+					Return([]models.OrderResponse{
+						{
+							ID:         "12345678903",
+							Status:     service.OrderProcessing,
+							UploadedAt: "date",
+						},
+					}, nil)
+			},
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+			},
+		},
+		{
+			name: "should return 204 if there are no pending orders",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					GetPendingOrders(gomock.Any()).
 					Return([]models.OrderResponse{}, nil)
 			},
 			want: want{
@@ -371,7 +612,7 @@ func Test_application_getOrdersHandler(t *testing.T) {
 			name: "should return 500 when internal error",
 			prepare: func(s *mocks.MockService) {
 				s.EXPECT().
-					GetUserOrders(gomock.Any()).
+					GetPendingOrders(gomock.Any()).
 					Return(nil, errors.New("internal error"))
 			},
 			want: want{
@@ -388,10 +629,10 @@ func Test_application_getOrdersHandler(t *testing.T) {
 			tt.prepare(service)
 			app.service = service
 
-			request := httptest.NewRequest(http.MethodGet, "/api/user/orders", nil)
+			request := httptest.NewRequest(http.MethodGet, "/api/user/balance/pending", nil)
 
 			w := httptest.NewRecorder()
-			app.getOrdersHandler(w, request)
+			app.getPendingOrdersHandler(w, request)
 
 			assert.Equal(t, tt.want.statusCode, w.Code)
 
@@ -423,8 +664,8 @@ func Test_application_getBalanceHandler(t *testing.T) {
 				s.EXPECT().
 					GetBalance(gomock.Any()).
 					Return(&models.BalanceResponse{
-						Current:   100,
-						Withdrawn: 75,
+						Current:   10000,
+						Withdrawn: 7500,
 					}, nil)
 			},
 			want: want{
@@ -467,6 +708,99 @@ func Test_application_getBalanceHandler(t *testing.T) {
 	}
 }
 
+func Test_application_getBalanceHistoryHandler(t *testing.T) {
+	app := application{
+		log: logger.NewLogger(),
+	}
+
+	type want struct {
+		statusCode  int
+		contentType string
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		prepare func(s *mocks.MockService)
+		want    want
+	}{
+		{
+			name:  "should successfully return balance history",
+			query: "?from=2023-08-01&to=2023-08-31",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					GetBalanceHistory(gomock.Any(),
+						time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC),
+						time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)).
+					Return([]models.BalanceHistoryResponse{
+						{
+							Type:      "ACCRUAL",
+							Reference: "12345678903",
+							Amount:    50000,
+							Balance:   50000,
+							CreatedAt: "date",
+						},
+					}, nil)
+			},
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+			},
+		},
+		{
+			name: "should return 204 if history is empty",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					GetBalanceHistory(gomock.Any(), time.Time{}, time.Time{}).
+					Return([]models.BalanceHistoryResponse{}, nil)
+			},
+			want: want{
+				statusCode: http.StatusNoContent,
+			},
+		},
+		{
+			name:    "should return 400 if date range is malformed",
+			query:   "?from=yesterday",
+			prepare: func(s *mocks.MockService) {},
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "should return 500 when internal error",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					GetBalanceHistory(gomock.Any(), time.Time{}, time.Time{}).
+					Return(nil, errors.New("internal error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+
+			tt.prepare(service)
+			app.service = service
+
+			request := httptest.NewRequest(http.MethodGet, "/api/user/balance/history"+tt.query, nil)
+
+			w := httptest.NewRecorder()
+			app.getBalanceHistoryHandler(w, request)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+
+			if tt.want.contentType != "" {
+				assert.Equal(t, tt.want.contentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
 func Test_application_withdrawHandler(t *testing.T) {
 	app := application{
 		log: logger.NewLogger(),
@@ -489,7 +823,7 @@ func Test_application_withdrawHandler(t *testing.T) {
 				s.EXPECT().
 					Withdraw(gomock.Any(), models.WithdrawRequest{
 						Order: "12345678903",
-						Sum:   12,
+						Sum:   1200,
 					}).
 					Return(nil)
 			},
@@ -497,34 +831,66 @@ func Test_application_withdrawHandler(t *testing.T) {
 				statusCode: http.StatusOK,
 			},
 		},
+		{
+			name:        "should return 400 when sum has more than two fractional digits",
+			requestBody: `{"order": "12345678903", "sum": 12.001}`,
+			prepare:     func(s *mocks.MockService) {},
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:        "should return 400 when sum is out of range",
+			requestBody: `{"order": "12345678903", "sum": 184467440737095516.17}`,
+			prepare:     func(s *mocks.MockService) {},
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
 		{
 			name:        "should return 422 when order id doesn't match luhn algorithm",
 			requestBody: `{"order": "1234567890", "sum": 12}`,
+			prepare:     func(s *mocks.MockService) {},
+			want: want{
+				statusCode: http.StatusUnprocessableEntity,
+			},
+		},
+		{
+			name:        "should return 400 when sum is negative",
+			requestBody: `{"order": "12345678903", "sum": -12}`,
+			prepare:     func(s *mocks.MockService) {},
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:        "should return 402 when balance is lower than withdraw sum",
+			requestBody: `{"order": "12345678903", "sum": 12}`,
 			prepare: func(s *mocks.MockService) {
 				s.EXPECT().
 					Withdraw(gomock.Any(), models.WithdrawRequest{
-						Order: "1234567890",
-						Sum:   12,
+						Order: "12345678903",
+						Sum:   1200,
 					}).
-					Return(service.ErrInvalidOrderID)
+					Return(service.ErrBalanceNotEnough)
 			},
 			want: want{
-				statusCode: http.StatusUnprocessableEntity,
+				statusCode: http.StatusPaymentRequired,
 			},
 		},
 		{
-			name:        "should return 402 when balance is lower than withdraw sum",
+			name:        "should return 409 when order number was already used for a withdrawal",
 			requestBody: `{"order": "12345678903", "sum": 12}`,
 			prepare: func(s *mocks.MockService) {
 				s.EXPECT().
 					Withdraw(gomock.Any(), models.WithdrawRequest{
 						Order: "12345678903",
-						Sum:   12,
+						Sum:   1200,
 					}).
-					Return(service.ErrBalanceNotEnough)
+					Return(service.ErrWithdrawalOrderUsed)
 			},
 			want: want{
-				statusCode: http.StatusPaymentRequired,
+				statusCode: http.StatusConflict,
 			},
 		},
 		{
@@ -534,7 +900,7 @@ func Test_application_withdrawHandler(t *testing.T) {
 				s.EXPECT().
 					Withdraw(gomock.Any(), models.WithdrawRequest{
 						Order: "12345678903",
-						Sum:   12,
+						Sum:   1200,
 					}).
 					Return(errors.New("internal error"))
 			},
@@ -564,6 +930,126 @@ func Test_application_withdrawHandler(t *testing.T) {
 	}
 }
 
+func Test_application_transferHandler(t *testing.T) {
+	app := application{
+		log: logger.NewLogger(),
+	}
+
+	type want struct {
+		statusCode int
+	}
+
+	tests := []struct {
+		name        string
+		requestBody string
+		prepare     func(s *mocks.MockService)
+		want        want
+	}{
+		{
+			name:        "should successfully transfer points",
+			requestBody: `{"login": "family", "sum": 12}`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					Transfer(gomock.Any(), models.TransferRequest{
+						Login: "family",
+						Sum:   1200,
+					}).
+					Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name:        "should return 400 when login is empty",
+			requestBody: `{"login": "", "sum": 12}`,
+			prepare:     func(s *mocks.MockService) {},
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:        "should return 400 when transferring to yourself",
+			requestBody: `{"login": "me", "sum": 12}`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					Transfer(gomock.Any(), gomock.Any()).
+					Return(service.ErrTransferToSelf)
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:        "should return 404 when recipient doesn't exist",
+			requestBody: `{"login": "nobody", "sum": 12}`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					Transfer(gomock.Any(), gomock.Any()).
+					Return(service.ErrRecipientNotFound)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name:        "should return 402 when balance is lower than transfer sum",
+			requestBody: `{"login": "family", "sum": 12}`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					Transfer(gomock.Any(), gomock.Any()).
+					Return(service.ErrBalanceNotEnough)
+			},
+			want: want{
+				statusCode: http.StatusPaymentRequired,
+			},
+		},
+		{
+			name:        "should return 403 when transfer limit is exceeded",
+			requestBody: `{"login": "family", "sum": 12}`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					Transfer(gomock.Any(), gomock.Any()).
+					Return(service.ErrTransferLimitExceeded)
+			},
+			want: want{
+				statusCode: http.StatusForbidden,
+			},
+		},
+		{
+			name:        "should return 500 when internal error",
+			requestBody: `{"login": "family", "sum": 12}`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					Transfer(gomock.Any(), gomock.Any()).
+					Return(errors.New("internal error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+
+			tt.prepare(service)
+
+			app.service = service
+
+			reader := strings.NewReader(tt.requestBody)
+			request := httptest.NewRequest(http.MethodPost, "/api/user/balance/transfer", reader)
+
+			w := httptest.NewRecorder()
+			app.transferHandler(w, request)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+		})
+	}
+}
+
 func Test_application_getWithdrawalsHandler(t *testing.T) {
 	app := application{
 		log: logger.NewLogger(),
@@ -572,10 +1058,12 @@ func Test_application_getWithdrawalsHandler(t *testing.T) {
 	type want struct {
 		statusCode  int
 		contentType string
+		nextCursor  string
 	}
 
 	tests := []struct {
 		name    string
+		query   string
 		prepare func(s *mocks.MockService)
 		want    want
 	}{
@@ -583,14 +1071,14 @@ func Test_application_getWithdrawalsHandler(t *testing.T) {
 			name: "should successfully return list of orders",
 			prepare: func(s *mocks.MockService) {
 				s.EXPECT().
-					GetUserWithdrawals(gomock.Any()).
+					GetUserWithdrawals(gomock.Any(), gomock.Any()).
 					Return([]models.WithdrawalsResponse{
 						{
 							Order:       "123",
-							Sum:         23,
+							Sum:         2300,
 							ProcessedAt: "date",
 						},
-					}, nil)
+					}, "", nil)
 			},
 			want: want{
 				statusCode:  http.StatusOK,
@@ -601,19 +1089,45 @@ func Test_application_getWithdrawalsHandler(t *testing.T) {
 			name: "should return 204 if list of withdrawals is empty",
 			prepare: func(s *mocks.MockService) {
 				s.EXPECT().
-					GetUserWithdrawals(gomock.Any()).
-					Return([]models.WithdrawalsResponse{}, nil)
+					GetUserWithdrawals(gomock.Any(), gomock.Any()).
+					Return([]models.WithdrawalsResponse{}, "", nil)
 			},
 			want: want{
 				statusCode: http.StatusNoContent,
 			},
 		},
+		{
+			name:  "should filter withdrawals by status and time",
+			query: "?status=reversed&from=2023-09-01&to=2023-09-30",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					GetUserWithdrawals(gomock.Any(), models.ListRequest{
+						Statuses: []string{"REVERSED"},
+						From:     time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC),
+						To:       time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC),
+					}).
+					Return([]models.WithdrawalsResponse{{Order: "123"}}, "", nil)
+			},
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+			},
+		},
+		{
+			name:    "should return 400 if status is not a withdrawal status",
+			query:   "?status=NEW",
+			prepare: func(s *mocks.MockService) {},
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "application/json",
+			},
+		},
 		{
 			name: "should return 500 when internal error",
 			prepare: func(s *mocks.MockService) {
 				s.EXPECT().
-					GetUserWithdrawals(gomock.Any()).
-					Return(nil, errors.New("internal error"))
+					GetUserWithdrawals(gomock.Any(), gomock.Any()).
+					Return(nil, "", errors.New("internal error"))
 			},
 			want: want{
 				statusCode: http.StatusInternalServerError,
@@ -629,7 +1143,7 @@ func Test_application_getWithdrawalsHandler(t *testing.T) {
 			tt.prepare(service)
 			app.service = service
 
-			request := httptest.NewRequest(http.MethodGet, "/api/user/orders", nil)
+			request := httptest.NewRequest(http.MethodGet, "/api/user/withdrawals"+tt.query, nil)
 
 			w := httptest.NewRecorder()
 			app.getWithdrawalsHandler(w, request)
@@ -639,6 +1153,144 @@ func Test_application_getWithdrawalsHandler(t *testing.T) {
 			if tt.want.contentType != "" {
 				assert.Equal(t, tt.want.contentType, w.Header().Get("Content-Type"))
 			}
+
+			assert.Equal(t, tt.want.nextCursor, w.Header().Get(nextCursorHeader))
+		})
+	}
+}
+
+func Test_application_reverseWithdrawalHandler(t *testing.T) {
+	app := application{
+		adminToken: "admin-token",
+		log:        logger.NewLogger(),
+	}
+
+	type want struct {
+		statusCode  int
+		contentType string
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		prepare func(s *mocks.MockService)
+		want    want
+	}{
+		{
+			name:  "should return reversed withdrawal",
+			token: "admin-token",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					ReverseWithdrawal(gomock.Any(), "12345678903").
+					Return(&models.WithdrawalsResponse{
+						Order:       "12345678903",
+						Sum:         2300,
+						Status:      service.WithdrawalReversed,
+						ProcessedAt: "date",
+						ReversedAt:  "date",
+					}, nil)
+			},
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+			},
+		},
+		{
+			name:    "should return 401 without admin token",
+			token:   "wrong-token",
+			prepare: func(s *mocks.MockService) {},
+			want: want{
+				statusCode: http.StatusUnauthorized,
+			},
+		},
+		{
+			name:  "should return 404 if withdrawal doesn't exist",
+			token: "admin-token",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					ReverseWithdrawal(gomock.Any(), "12345678903").
+					Return(nil, service.ErrWithdrawalNotFound)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name:  "should return 500 when internal error",
+			token: "admin-token",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					ReverseWithdrawal(gomock.Any(), "12345678903").
+					Return(nil, errors.New("internal error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+
+			tt.prepare(service)
+			app.service = service
+
+			request := httptest.NewRequest(http.MethodPost, "/api/admin/withdrawals/12345678903/reverse", nil)
+			request.Header.Set("Authorization", "Bearer "+tt.token)
+
+			w := httptest.NewRecorder()
+			app.Router().ServeHTTP(w, request)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+
+			if tt.want.contentType != "" {
+				assert.Equal(t, tt.want.contentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func Test_application_metricsHandler(t *testing.T) {
+	app := application{
+		adminToken: "admin-token",
+		log:        logger.NewLogger(),
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		statusCode int
+	}{
+		{
+			name:       "should serve accrual metrics to admin",
+			token:      "admin-token",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "should return 401 without admin token",
+			statusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+			if tt.token != "" {
+				request.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			w := httptest.NewRecorder()
+			app.Router().ServeHTTP(w, request)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+
+			if tt.statusCode == http.StatusOK {
+				assert.Contains(t, w.Body.String(), "accrual_queue_depth")
+				assert.NotContains(t, w.Body.String(), "cmdline")
+				assert.NotContains(t, w.Body.String(), "memstats")
+			}
 		})
 	}
 }
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/service"
+	"github.com/PrahaTurbo/gophermart/internal/validator"
+)
+
+func (a *application) holdHandler(w http.ResponseWriter, r *http.Request) {
+	var holdReq models.HoldRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&holdReq); err != nil {
+		if ok, fieldErr := amountFieldError("sum", err); ok {
+			a.writeValidationError(w, http.StatusBadRequest, fieldErr)
+			return
+		}
+
+		a.log.Error().Err(err).Msg("cannot unmarshal request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := holdReq.Validate(); err != nil {
+		status := http.StatusBadRequest
+
+		var fieldErrs validator.Errors
+		if errors.As(err, &fieldErrs) && fieldErrs.Has("order") {
+			status = http.StatusUnprocessableEntity
+		}
+
+		a.writeValidationError(w, status, err)
+		return
+	}
+
+	hold, err := a.service.HoldPoints(r.Context(), holdReq)
+
+	if errors.Is(err, service.ErrInvalidOrderID) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	if errors.Is(err, service.ErrInvalidWithdrawSum) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if errors.Is(err, service.ErrBalanceNotEnough) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		return
+	}
+
+	if errors.Is(err, service.ErrWithdrawalOrderUsed) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	a.writeHold(w, hold)
+}
+
+func (a *application) captureHoldHandler(w http.ResponseWriter, r *http.Request) {
+	hold, err := a.service.CaptureHold(r.Context(), chi.URLParam(r, "order"))
+	a.writeResolvedHold(w, hold, err)
+}
+
+func (a *application) releaseHoldHandler(w http.ResponseWriter, r *http.Request) {
+	hold, err := a.service.ReleaseHold(r.Context(), chi.URLParam(r, "order"))
+	a.writeResolvedHold(w, hold, err)
+}
+
+func (a *application) writeResolvedHold(w http.ResponseWriter, hold *models.HoldResponse, err error) {
+	if errors.Is(err, service.ErrHoldNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, service.ErrHoldNotActive) || errors.Is(err, service.ErrWithdrawalOrderUsed) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	a.writeHold(w, hold)
+}
+
+func (a *application) writeHold(w http.ResponseWriter, hold *models.HoldResponse) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(hold); err != nil {
+		a.log.Error().Err(err).Msg("cannot encode hold")
+		return
+	}
+}
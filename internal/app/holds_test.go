@@ -0,0 +1,220 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/service"
+)
+
+func Test_application_holdHandler(t *testing.T) {
+	app := application{
+		log: logger.NewLogger(),
+	}
+
+	type want struct {
+		statusCode  int
+		contentType string
+	}
+
+	tests := []struct {
+		name        string
+		requestBody string
+		prepare     func(s *mocks.MockService)
+		want        want
+	}{
+		{
+			name:        "should successfully hold points",
+			requestBody: `{"order": "12345678903", "sum": 12}`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					HoldPoints(gomock.Any(), models.HoldRequest{
+						Order: "12345678903",
+						Sum:   1200,
+					}).
+					Return(&models.HoldResponse{
+						Order:  "12345678903",
+						Sum:    1200,
+						Status: "HELD",
+					}, nil)
+			},
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+			},
+		},
+		{
+			name:        "should return 422 when order id doesn't match luhn algorithm",
+			requestBody: `{"order": "1234567890", "sum": 12}`,
+			prepare:     func(s *mocks.MockService) {},
+			want: want{
+				statusCode:  http.StatusUnprocessableEntity,
+				contentType: "application/json",
+			},
+		},
+		{
+			name:        "should return 400 when sum is negative",
+			requestBody: `{"order": "12345678903", "sum": -12}`,
+			prepare:     func(s *mocks.MockService) {},
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "application/json",
+			},
+		},
+		{
+			name:        "should return 402 when balance is lower than hold sum",
+			requestBody: `{"order": "12345678903", "sum": 12}`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					HoldPoints(gomock.Any(), gomock.Any()).
+					Return(nil, service.ErrBalanceNotEnough)
+			},
+			want: want{
+				statusCode: http.StatusPaymentRequired,
+			},
+		},
+		{
+			name:        "should return 409 when order number was already used",
+			requestBody: `{"order": "12345678903", "sum": 12}`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					HoldPoints(gomock.Any(), gomock.Any()).
+					Return(nil, service.ErrWithdrawalOrderUsed)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+			},
+		},
+		{
+			name:        "should return 500 when internal error",
+			requestBody: `{"order": "12345678903", "sum": 12}`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					HoldPoints(gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("internal error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+
+			tt.prepare(service)
+
+			app.service = service
+
+			reader := strings.NewReader(tt.requestBody)
+			request := httptest.NewRequest(http.MethodPost, "/api/user/balance/holds", reader)
+
+			w := httptest.NewRecorder()
+			app.holdHandler(w, request)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+
+			if tt.want.contentType != "" {
+				assert.Equal(t, tt.want.contentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func Test_application_captureHoldHandler(t *testing.T) {
+	app := application{
+		log: logger.NewLogger(),
+	}
+
+	type want struct {
+		statusCode int
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *mocks.MockService)
+		want    want
+	}{
+		{
+			name: "should capture hold",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					CaptureHold(gomock.Any(), "12345678903").
+					Return(&models.HoldResponse{
+						Order:  "12345678903",
+						Sum:    1200,
+						Status: "CAPTURED",
+					}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "should return 404 when hold doesn't exist",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					CaptureHold(gomock.Any(), "12345678903").
+					Return(nil, service.ErrHoldNotFound)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "should return 409 when hold is not active",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					CaptureHold(gomock.Any(), "12345678903").
+					Return(nil, service.ErrHoldNotActive)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+			},
+		},
+		{
+			name: "should return 500 when internal error",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					CaptureHold(gomock.Any(), "12345678903").
+					Return(nil, errors.New("internal error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+
+			tt.prepare(service)
+
+			app.service = service
+
+			r := chi.NewRouter()
+			r.Post("/api/user/balance/holds/{order}/capture", app.captureHoldHandler)
+
+			request := httptest.NewRequest(http.MethodPost, "/api/user/balance/holds/12345678903/capture", nil)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, request)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+		})
+	}
+}
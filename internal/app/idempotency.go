@@ -0,0 +1,127 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/auth"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/service"
+	"github.com/PrahaTurbo/gophermart/internal/validator"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotencyKeyMaxLength = 255
+
+	// idempotencyStoreTimeout bounds storing the outcome of a request, which
+	// runs detached from the request so a client that has gone away doesn't
+	// leave the key locked.
+	idempotencyStoreTimeout = time.Second * 5
+)
+
+// idempotent makes next safe to retry: the first response for an
+// Idempotency-Key is stored and replayed for every repeated request with the
+// same key. Requests without the header are passed through unchanged.
+func (a *application) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		v := validator.New()
+		v.Check(len(key) <= idempotencyKeyMaxLength, idempotencyKeyHeader, "must be at most 255 characters long")
+		if err := v.Err(); err != nil {
+			a.writeValidationError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := append([]byte(r.Method+" "+r.URL.Path+"\n"), body...)
+
+		stored, err := a.service.BeginIdempotentRequest(r.Context(), key, fingerprint)
+		if errors.Is(err, service.ErrIdempotencyKeyReused) || errors.Is(err, service.ErrIdempotentRequestRunning) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if stored != nil {
+			if stored.ContentType != "" {
+				w.Header().Set("content-type", stored.ContentType)
+			}
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.Body)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next(rec, r)
+
+		// The request context is cancelled once the client disconnects, while
+		// the handler's changes are already committed; the outcome has to be
+		// stored regardless, or a retry would run the handler again.
+		userID, _ := r.Context().Value(auth.UserIDKey).(int)
+
+		ctx, cancel := context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+		defer cancel()
+
+		if rec.statusCode >= http.StatusInternalServerError {
+			if err := a.service.ReleaseIdempotencyKey(ctx, userID, key); err != nil {
+				a.log.Error().Err(err).Str("key", key).Msg("failed to release idempotency key")
+			}
+			return
+		}
+
+		resp := models.IdempotentResponse{
+			StatusCode:  rec.statusCode,
+			ContentType: rec.Header().Get("content-type"),
+			Body:        rec.body.Bytes(),
+		}
+
+		if err := a.service.CompleteIdempotentRequest(ctx, userID, key, resp); err != nil {
+			a.log.Error().Err(err).Str("key", key).Msg("failed to store idempotent response")
+		}
+	}
+}
+
+// responseRecorder passes the response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,225 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/auth"
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/service"
+)
+
+func Test_application_idempotent(t *testing.T) {
+	app := application{
+		log: logger.NewLogger(),
+	}
+
+	const body = `{"order": "12345678903", "sum": 12}`
+	fingerprint := []byte("POST /api/user/balance/withdraw\n" + body)
+
+	type want struct {
+		statusCode int
+		body       string
+		handled    bool
+	}
+
+	tests := []struct {
+		name          string
+		key           string
+		handlerStatus int
+		prepare       func(s *mocks.MockService)
+		want          want
+	}{
+		{
+			name:          "should pass request through without key",
+			handlerStatus: http.StatusOK,
+			prepare:       func(s *mocks.MockService) {},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "handled",
+				handled:    true,
+			},
+		},
+		{
+			name:          "should handle request and store response for new key",
+			key:           "key",
+			handlerStatus: http.StatusPaymentRequired,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					BeginIdempotentRequest(gomock.Any(), "key", fingerprint).
+					Return(nil, nil)
+				s.EXPECT().
+					CompleteIdempotentRequest(gomock.Any(), 1, "key", models.IdempotentResponse{
+						StatusCode: http.StatusPaymentRequired,
+						Body:       []byte("handled"),
+					}).
+					Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusPaymentRequired,
+				body:       "handled",
+				handled:    true,
+			},
+		},
+		{
+			name:          "should release key when handler fails",
+			key:           "key",
+			handlerStatus: http.StatusInternalServerError,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					BeginIdempotentRequest(gomock.Any(), "key", fingerprint).
+					Return(nil, nil)
+				s.EXPECT().
+					ReleaseIdempotencyKey(gomock.Any(), 1, "key").
+					Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				body:       "handled",
+				handled:    true,
+			},
+		},
+		{
+			name: "should replay stored response",
+			key:  "key",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					BeginIdempotentRequest(gomock.Any(), "key", fingerprint).
+					Return(&models.IdempotentResponse{
+						StatusCode: http.StatusOK,
+						Body:       []byte("stored"),
+					}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "stored",
+			},
+		},
+		{
+			name: "should return 409 when key was used for another request",
+			key:  "key",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					BeginIdempotentRequest(gomock.Any(), "key", fingerprint).
+					Return(nil, service.ErrIdempotencyKeyReused)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+			},
+		},
+		{
+			name: "should return 409 when first request is still running",
+			key:  "key",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					BeginIdempotentRequest(gomock.Any(), "key", fingerprint).
+					Return(nil, service.ErrIdempotentRequestRunning)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+			},
+		},
+		{
+			name:    "should return 400 when key is too long",
+			key:     strings.Repeat("k", idempotencyKeyMaxLength+1),
+			prepare: func(s *mocks.MockService) {},
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "should return 500 when can't claim the key",
+			key:  "key",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					BeginIdempotentRequest(gomock.Any(), "key", fingerprint).
+					Return(nil, errors.New("internal error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+
+			tt.prepare(service)
+
+			app.service = service
+
+			var handled bool
+			next := func(w http.ResponseWriter, r *http.Request) {
+				handled = true
+				w.WriteHeader(tt.handlerStatus)
+				w.Write([]byte("handled"))
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw", strings.NewReader(body))
+			request = request.WithContext(context.WithValue(request.Context(), auth.UserIDKey, 1))
+			if tt.key != "" {
+				request.Header.Set(idempotencyKeyHeader, tt.key)
+			}
+
+			w := httptest.NewRecorder()
+			app.idempotent(next)(w, request)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			assert.Equal(t, tt.want.handled, handled)
+			if tt.want.body != "" {
+				assert.Equal(t, tt.want.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func Test_application_idempotent_cancelledRequest(t *testing.T) {
+	app := application{
+		log: logger.NewLogger(),
+	}
+
+	ctrl := gomock.NewController(t)
+	service := mocks.NewMockService(ctrl)
+	app.service = service
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), auth.UserIDKey, 1))
+
+	service.EXPECT().
+		BeginIdempotentRequest(gomock.Any(), "key", gomock.Any()).
+		Return(nil, nil)
+	service.EXPECT().
+		CompleteIdempotentRequest(gomock.Any(), 1, "key", models.IdempotentResponse{
+			StatusCode: http.StatusOK,
+			Body:       []byte("handled"),
+		}).
+		DoAndReturn(func(ctx context.Context, userID int, key string, resp models.IdempotentResponse) error {
+			assert.NoError(t, ctx.Err())
+			return nil
+		})
+
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("handled"))
+		// The client goes away after the handler has done its work.
+		cancel()
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/api/user/balance/transfer", strings.NewReader("{}")).WithContext(ctx)
+	request.Header.Set(idempotencyKeyHeader, "key")
+
+	w := httptest.NewRecorder()
+	app.idempotent(next)(w, request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/service"
+	"github.com/PrahaTurbo/gophermart/internal/validator"
+)
+
+const maxBatchBodyBytes = 1 << 20
+
+// processOrdersBatchHandler uploads a batch of order numbers given either as
+// a JSON array of strings or as plain text with one number per line.
+func (a *application) processOrdersBatchHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	orderIDs, err := parseOrderBatch(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		a.log.Error().Err(err).Msg("cannot unmarshal response")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	v := validator.New()
+	v.Check(len(orderIDs) > 0, "orders", "must not be empty")
+	v.Check(len(orderIDs) <= service.MaxBatchOrders, "orders", fmt.Sprintf("must not contain more than %d numbers", service.MaxBatchOrders))
+	if err := v.Err(); err != nil {
+		a.writeValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	results, err := a.service.ProcessOrders(r.Context(), orderIDs)
+	if errors.Is(err, service.ErrTooManyOrders) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// parseOrderBatch reads the order numbers of a batch, skipping blank lines of
+// a plain text body.
+func parseOrderBatch(contentType string, body []byte) ([]string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/json" {
+		var orderIDs []string
+		if err := json.Unmarshal(body, &orderIDs); err != nil {
+			return nil, err
+		}
+
+		for i := range orderIDs {
+			orderIDs[i] = strings.TrimSpace(orderIDs[i])
+		}
+
+		return orderIDs, nil
+	}
+
+	var orderIDs []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			orderIDs = append(orderIDs, line)
+		}
+	}
+
+	return orderIDs, scanner.Err()
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/service"
+)
+
+func Test_application_processOrdersBatchHandler(t *testing.T) {
+	app := application{
+		log: logger.NewLogger(),
+	}
+
+	results := []models.OrderUploadResult{
+		{Number: "12345678903", Status: service.UploadAccepted},
+		{Number: "123", Status: service.UploadInvalidOrderID},
+	}
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		requestBody string
+		prepare     func(s *mocks.MockService)
+		want        want
+	}{
+		{
+			name:        "should upload orders given as JSON array",
+			contentType: "application/json; charset=utf-8",
+			requestBody: `["12345678903", " 123 "]`,
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					ProcessOrders(gomock.Any(), []string{"12345678903", "123"}).
+					Return(results, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `[{"number":"12345678903","status":"ACCEPTED"},{"number":"123","status":"INVALID"}]`,
+			},
+		},
+		{
+			name:        "should upload orders given as lines of text",
+			contentType: "text/plain",
+			requestBody: "12345678903\r\n\n123\n",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					ProcessOrders(gomock.Any(), []string{"12345678903", "123"}).
+					Return(results, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `[{"number":"12345678903","status":"ACCEPTED"},{"number":"123","status":"INVALID"}]`,
+			},
+		},
+		{
+			name:        "should return 400 if batch is empty",
+			contentType: "text/plain",
+			requestBody: "\n\n",
+			prepare:     func(s *mocks.MockService) {},
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:        "should return 400 if JSON is malformed",
+			contentType: "application/json",
+			requestBody: `[12345678903]`,
+			prepare:     func(s *mocks.MockService) {},
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:        "should return 500 when internal error",
+			contentType: "text/plain",
+			requestBody: "12345678903",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					ProcessOrders(gomock.Any(), []string{"12345678903"}).
+					Return(nil, errors.New("internal error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+
+			tt.prepare(service)
+
+			app.service = service
+
+			request := httptest.NewRequest(http.MethodPost, "/api/user/orders/batch", strings.NewReader(tt.requestBody))
+			request.Header.Set("Content-Type", tt.contentType)
+
+			w := httptest.NewRecorder()
+			app.processOrdersBatchHandler(w, request)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+
+			if tt.want.body != "" {
+				assert.JSONEq(t, tt.want.body, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/service"
+	"github.com/PrahaTurbo/gophermart/internal/validator"
+)
+
+const (
+	dateLayout  = "2006-01-02"
+	msgDateTime = "must be a date or RFC3339 time"
+
+	defaultListLimit = 100
+	nextCursorHeader = "X-Next-Cursor"
+)
+
+var (
+	orderStatuses = []string{
+		service.OrderNew,
+		service.OrderRegistered,
+		service.OrderProcessing,
+		service.OrderInvalid,
+		service.OrderProcessed,
+		service.OrderUnresolved,
+	}
+	withdrawalStatuses = []string{service.WithdrawalProcessed, service.WithdrawalReversed}
+)
+
+// parseDateParam parses a query parameter given either as RFC3339 time or as
+// a date. A date used as an upper bound points to the start of the next day,
+// so the whole day falls into the range. An empty value gives zero time.
+func parseDateParam(value string, upperBound bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if upperBound {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t, nil
+}
+
+func parseBalanceHistoryRequest(r *http.Request) (models.BalanceHistoryRequest, error) {
+	v := validator.New()
+
+	from, err := parseDateParam(r.URL.Query().Get("from"), false)
+	v.Check(err == nil, "from", msgDateTime)
+
+	to, err := parseDateParam(r.URL.Query().Get("to"), true)
+	v.Check(err == nil, "to", msgDateTime)
+
+	if err := v.Err(); err != nil {
+		return models.BalanceHistoryRequest{}, err
+	}
+
+	req := models.BalanceHistoryRequest{From: from, To: to}
+
+	return req, req.Validate()
+}
+
+// parseListRequest reads the paging, filtering and sorting parameters of a
+// list. Without limit and cursor the whole list is returned, as it was before
+// lists were paged; a cursor without a limit gives pages of defaultListLimit.
+// Statuses may be given comma separated or as repeated parameters, and each
+// must be one of allowedStatuses.
+func parseListRequest(r *http.Request, allowedStatuses []string) (models.ListRequest, error) {
+	query := r.URL.Query()
+	v := validator.New()
+
+	req := models.ListRequest{Cursor: query.Get("cursor")}
+
+	if req.Cursor != "" {
+		req.Limit = defaultListLimit
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		v.Check(err == nil, "limit", "must be a number")
+		v.Check(err != nil || n > 0, "limit", "must be from 1 to 1000")
+		req.Limit = n
+	}
+
+	for _, value := range query["status"] {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.ToUpper(strings.TrimSpace(status))
+			if status == "" {
+				continue
+			}
+
+			v.Check(contains(allowedStatuses, status), "status", "must be one of "+strings.Join(allowedStatuses, ", "))
+			req.Statuses = append(req.Statuses, status)
+		}
+	}
+
+	var err error
+
+	req.From, err = parseDateParam(query.Get("from"), false)
+	v.Check(err == nil, "from", msgDateTime)
+
+	req.To, err = parseDateParam(query.Get("to"), true)
+	v.Check(err == nil, "to", msgDateTime)
+
+	switch strings.ToLower(query.Get("sort")) {
+	case "", "asc":
+	case "desc":
+		req.Desc = true
+	default:
+		v.Check(false, "sort", "must be asc or desc")
+	}
+
+	if err := v.Err(); err != nil {
+		return models.ListRequest{}, err
+	}
+
+	return req, req.Validate()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
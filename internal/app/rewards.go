@@ -0,0 +1,177 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/service"
+)
+
+func (a *application) createRewardHandler(w http.ResponseWriter, r *http.Request) {
+	rewardReq, ok := a.decodeRewardRequest(w, r)
+	if !ok {
+		return
+	}
+
+	reward, err := a.service.CreateReward(r.Context(), rewardReq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(reward); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *application) updateRewardHandler(w http.ResponseWriter, r *http.Request) {
+	rewardID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	rewardReq, ok := a.decodeRewardRequest(w, r)
+	if !ok {
+		return
+	}
+
+	reward, err := a.service.UpdateReward(r.Context(), rewardID, rewardReq)
+	if errors.Is(err, service.ErrRewardNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(reward); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *application) getRewardsHandler(w http.ResponseWriter, r *http.Request) {
+	a.writeRewards(w, r, false)
+}
+
+func (a *application) getAllRewardsHandler(w http.ResponseWriter, r *http.Request) {
+	a.writeRewards(w, r, true)
+}
+
+func (a *application) redeemHandler(w http.ResponseWriter, r *http.Request) {
+	rewardID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	redemption, err := a.service.Redeem(r.Context(), rewardID)
+	if errors.Is(err, service.ErrRewardNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, service.ErrRewardOutOfStock) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	if errors.Is(err, service.ErrBalanceNotEnough) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(redemption); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (a *application) getRedemptionsHandler(w http.ResponseWriter, r *http.Request) {
+	redemptions, err := a.service.GetUserRedemptions(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if len(redemptions) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(redemptions); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// decodeRewardRequest reads and validates the reward of the request body,
+// responding with 400 if it is malformed.
+func (a *application) decodeRewardRequest(w http.ResponseWriter, r *http.Request) (models.RewardRequest, bool) {
+	var rewardReq models.RewardRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&rewardReq); err != nil {
+		if ok, fieldErr := amountFieldError("price", err); ok {
+			a.writeValidationError(w, http.StatusBadRequest, fieldErr)
+			return rewardReq, false
+		}
+
+		a.log.Error().Err(err).Msg("cannot unmarshal response")
+		w.WriteHeader(http.StatusBadRequest)
+		return rewardReq, false
+	}
+
+	if err := rewardReq.Validate(); err != nil {
+		a.writeValidationError(w, http.StatusBadRequest, err)
+		return rewardReq, false
+	}
+
+	return rewardReq, true
+}
+
+func (a *application) writeRewards(w http.ResponseWriter, r *http.Request, all bool) {
+	rewards, err := a.service.GetRewards(r.Context(), all)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if len(rewards) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(rewards); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/service"
+)
+
+func Test_application_redeemHandler(t *testing.T) {
+	app := application{
+		log: logger.NewLogger(),
+	}
+
+	type want struct {
+		statusCode  int
+		contentType string
+	}
+
+	tests := []struct {
+		name     string
+		rewardID string
+		prepare  func(s *mocks.MockService)
+		want     want
+	}{
+		{
+			name:     "should redeem reward",
+			rewardID: "7",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					Redeem(gomock.Any(), 7).
+					Return(&models.RedemptionResponse{
+						ID:       1,
+						RewardID: 7,
+						Name:     "mug",
+						Price:    50000,
+					}, nil)
+			},
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+			},
+		},
+		{
+			name:     "should return 404 when reward id is not a number",
+			rewardID: "mug",
+			prepare:  func(s *mocks.MockService) {},
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name:     "should return 404 when reward doesn't exist",
+			rewardID: "7",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					Redeem(gomock.Any(), 7).
+					Return(nil, service.ErrRewardNotFound)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name:     "should return 409 when reward is out of stock",
+			rewardID: "7",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					Redeem(gomock.Any(), 7).
+					Return(nil, service.ErrRewardOutOfStock)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+			},
+		},
+		{
+			name:     "should return 402 when balance is not enough",
+			rewardID: "7",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					Redeem(gomock.Any(), 7).
+					Return(nil, service.ErrBalanceNotEnough)
+			},
+			want: want{
+				statusCode: http.StatusPaymentRequired,
+			},
+		},
+		{
+			name:     "should return 500 when internal error",
+			rewardID: "7",
+			prepare: func(s *mocks.MockService) {
+				s.EXPECT().
+					Redeem(gomock.Any(), 7).
+					Return(nil, errors.New("internal error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mocks.NewMockService(ctrl)
+
+			tt.prepare(service)
+
+			app.service = service
+
+			r := chi.NewRouter()
+			r.Post("/api/user/rewards/{id}/redeem", app.redeemHandler)
+
+			request := httptest.NewRequest(http.MethodPost, "/api/user/rewards/"+tt.rewardID+"/redeem", nil)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, request)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+
+			if tt.want.contentType != "" {
+				assert.Equal(t, tt.want.contentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
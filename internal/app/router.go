@@ -17,10 +17,34 @@ func (a *application) Router() chi.Router {
 		r.Use(auth.Auth(a.jwtSecret))
 
 		r.Post("/api/user/orders", a.processOrderHandler)
+		r.Post("/api/user/orders/batch", a.processOrdersBatchHandler)
 		r.Get("/api/user/orders", a.getOrdersHandler)
+		r.Get("/api/user/orders/{number}", a.getOrderHandler)
+		r.Get("/api/user/referral", a.getReferralHandler)
 		r.Get("/api/user/balance", a.getBalanceHandler)
-		r.Post("/api/user/balance/withdraw", a.withdrawHandler)
+		r.Get("/api/user/balance/history", a.getBalanceHistoryHandler)
+		r.Get("/api/user/balance/pending", a.getPendingOrdersHandler)
+		r.Post("/api/user/balance/withdraw", a.idempotent(a.withdrawHandler))
 		r.Get("/api/user/withdrawals", a.getWithdrawalsHandler)
+		r.Post("/api/user/balance/transfer", a.idempotent(a.transferHandler))
+		r.Post("/api/user/balance/holds", a.idempotent(a.holdHandler))
+		r.Post("/api/user/balance/holds/{order}/capture", a.captureHoldHandler)
+		r.Post("/api/user/balance/holds/{order}/release", a.releaseHoldHandler)
+		r.Get("/api/user/rewards", a.getRewardsHandler)
+		r.Post("/api/user/rewards/{id}/redeem", a.idempotent(a.redeemHandler))
+		r.Get("/api/user/redemptions", a.getRedemptionsHandler)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.AdminAuth(a.adminToken))
+
+		r.Post("/api/admin/withdrawals/{order}/reverse", a.reverseWithdrawalHandler)
+		r.Post("/api/admin/campaigns", a.createCampaignHandler)
+		r.Get("/api/admin/campaigns", a.getCampaignsHandler)
+		r.Post("/api/admin/rewards", a.createRewardHandler)
+		r.Get("/api/admin/rewards", a.getAllRewardsHandler)
+		r.Put("/api/admin/rewards/{id}", a.updateRewardHandler)
+		r.Get("/debug/vars", a.metricsHandler)
 	})
 
 	r.Group(func(r chi.Router) {
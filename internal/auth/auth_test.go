@@ -2,6 +2,7 @@ package auth
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -56,3 +57,52 @@ func genJWTToken(userID int) string {
 
 	return tokenString
 }
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		adminToken    string
+		authorization string
+		want          int
+	}{
+		{
+			name:          "should let request with admin token through",
+			adminToken:    "admin-token",
+			authorization: "Bearer admin-token",
+			want:          http.StatusOK,
+		},
+		{
+			name:          "should reject request with wrong token",
+			adminToken:    "admin-token",
+			authorization: "Bearer wrong-token",
+			want:          http.StatusUnauthorized,
+		},
+		{
+			name:       "should reject request without token",
+			adminToken: "admin-token",
+			want:       http.StatusUnauthorized,
+		},
+		{
+			name:          "should reject every request if admin token is not configured",
+			authorization: "Bearer ",
+			want:          http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			request := httptest.NewRequest(http.MethodPost, "/api/admin", nil)
+			if tt.authorization != "" {
+				request.Header.Set("Authorization", tt.authorization)
+			}
+
+			w := httptest.NewRecorder()
+			AdminAuth(tt.adminToken)(next).ServeHTTP(w, request)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
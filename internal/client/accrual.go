@@ -1,28 +1,50 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/PrahaTurbo/gophermart/internal/models"
 )
 
+const defaultRetryAfter = time.Second * 60
+
+var ErrOrderNotRegistered = errors.New("order is not registered in accrual system")
+
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("accrual system rate limit exceeded, retry after %s", e.RetryAfter)
+}
+
 type AccrualClient struct {
 	baseURL string
 	client  *http.Client
+	limiter *Limiter
 }
 
-func NewAccrualClient(baseURL string) *AccrualClient {
+func NewAccrualClient(baseURL string, rateLimit int) *AccrualClient {
 	return &AccrualClient{
 		baseURL: baseURL,
 		client:  &http.Client{},
+		limiter: NewLimiter(rateLimit),
 	}
 }
 
-func (c *AccrualClient) GetAccrual(orderID string) (*models.AccrualResponse, error) {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/orders/%s", c.baseURL, orderID), nil)
+func (c *AccrualClient) GetAccrual(ctx context.Context, orderID string) (*models.AccrualResponse, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/orders/%s", c.baseURL, orderID), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -33,6 +55,17 @@ func (c *AccrualClient) GetAccrual(orderID string) (*models.AccrualResponse, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+		c.limiter.Pause(retryAfter)
+
+		return nil, &RateLimitError{RetryAfter: retryAfter}
+	}
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, ErrOrderNotRegistered
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
@@ -49,3 +82,21 @@ func (c *AccrualClient) GetAccrual(orderID string) (*models.AccrualResponse, err
 
 	return &accrualResponse, nil
 }
+
+// parseRetryAfter accepts both forms of the header: a number of seconds or
+// an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+
+		return 0
+	}
+
+	return defaultRetryAfter
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+)
+
+func TestAccrualClient_GetAccrual(t *testing.T) {
+	t.Run("should return rate limit error with retry delay", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		c := NewAccrualClient(server.URL, 0)
+
+		_, err := c.GetAccrual(context.Background(), "12345678903")
+
+		var rateLimitErr *RateLimitError
+		require.ErrorAs(t, err, &rateLimitErr)
+		assert.Equal(t, time.Second*60, rateLimitErr.RetryAfter)
+	})
+
+	t.Run("should not call accrual system while paused", func(t *testing.T) {
+		var calls atomic.Int32
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		c := NewAccrualClient(server.URL, 0)
+
+		_, err := c.GetAccrual(context.Background(), "12345678903")
+		require.Error(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+		defer cancel()
+
+		_, err = c.GetAccrual(ctx, "12345678903")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("should return not registered error on 204", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		c := NewAccrualClient(server.URL, 0)
+
+		_, err := c.GetAccrual(context.Background(), "12345678903")
+		assert.ErrorIs(t, err, ErrOrderNotRegistered)
+	})
+
+	t.Run("should return accrual response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/orders/12345678903", r.URL.Path)
+			w.Write([]byte(`{"order": "12345678903", "status": "PROCESSED", "accrual": 500}`))
+		}))
+		defer server.Close()
+
+		c := NewAccrualClient(server.URL, 0)
+
+		resp, err := c.GetAccrual(context.Background(), "12345678903")
+		require.NoError(t, err)
+		assert.Equal(t, "PROCESSED", resp.Status)
+		assert.Equal(t, models.Amount(50000), resp.Accrual)
+	})
+
+	t.Run("should reject accrual with more than two fractional digits", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"order": "12345678903", "status": "PROCESSED", "accrual": 500.125}`))
+		}))
+		defer server.Close()
+
+		c := NewAccrualClient(server.URL, 0)
+
+		_, err := c.GetAccrual(context.Background(), "12345678903")
+		assert.ErrorIs(t, err, models.ErrAmountPrecision)
+	})
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{
+			name:  "should parse seconds",
+			value: "120",
+			want:  time.Second * 120,
+		},
+		{
+			name:  "should fall back to default if header is missing",
+			value: "",
+			want:  defaultRetryAfter,
+		},
+		{
+			name:  "should return zero for a date in the past",
+			value: "Wed, 21 Oct 2015 07:28:00 GMT",
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseRetryAfter(tt.value))
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter spaces requests to the accrual system out to at most rps per
+// second and holds back every request while the system asked us to slow down.
+type Limiter struct {
+	mu          sync.Mutex
+	interval    time.Duration
+	next        time.Time
+	pausedUntil time.Time
+}
+
+// NewLimiter creates a limiter allowing rps requests per second. A
+// non-positive rps disables the rate limit, pauses still apply.
+func NewLimiter(rps int) *Limiter {
+	l := &Limiter{}
+	if rps > 0 {
+		l.interval = time.Second / time.Duration(rps)
+	}
+
+	return l
+}
+
+// Pause blocks requests for d. A shorter pause never cuts an existing one.
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// Wait returns once a request is allowed or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+
+		slot := now
+		if l.pausedUntil.After(slot) {
+			slot = l.pausedUntil
+		}
+		if l.next.After(slot) {
+			slot = l.next
+		}
+
+		if !slot.After(now) {
+			l.next = now.Add(l.interval)
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(slot.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
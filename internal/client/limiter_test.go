@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Wait(t *testing.T) {
+	t.Run("should space requests out by the rate limit", func(t *testing.T) {
+		l := NewLimiter(20)
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, l.Wait(context.Background()))
+		}
+
+		assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*100)
+	})
+
+	t.Run("should not wait without rate limit", func(t *testing.T) {
+		l := NewLimiter(0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+		defer cancel()
+
+		for i := 0; i < 100; i++ {
+			require.NoError(t, l.Wait(ctx))
+		}
+	})
+
+	t.Run("should wait until pause has passed", func(t *testing.T) {
+		l := NewLimiter(0)
+		l.Pause(time.Millisecond * 50)
+
+		start := time.Now()
+		require.NoError(t, l.Wait(context.Background()))
+
+		assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*50)
+	})
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 
@@ -36,6 +37,80 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// BeginIdempotentRequest mocks base method.
+func (m *MockService) BeginIdempotentRequest(ctx context.Context, key string, request []byte) (*models.IdempotentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginIdempotentRequest", ctx, key, request)
+	ret0, _ := ret[0].(*models.IdempotentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginIdempotentRequest indicates an expected call of BeginIdempotentRequest.
+func (mr *MockServiceMockRecorder) BeginIdempotentRequest(ctx, key, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginIdempotentRequest", reflect.TypeOf((*MockService)(nil).BeginIdempotentRequest), ctx, key, request)
+}
+
+// CaptureHold mocks base method.
+func (m *MockService) CaptureHold(ctx context.Context, orderID string) (*models.HoldResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureHold", ctx, orderID)
+	ret0, _ := ret[0].(*models.HoldResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaptureHold indicates an expected call of CaptureHold.
+func (mr *MockServiceMockRecorder) CaptureHold(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureHold", reflect.TypeOf((*MockService)(nil).CaptureHold), ctx, orderID)
+}
+
+// CompleteIdempotentRequest mocks base method.
+func (m *MockService) CompleteIdempotentRequest(ctx context.Context, userID int, key string, resp models.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteIdempotentRequest", ctx, userID, key, resp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteIdempotentRequest indicates an expected call of CompleteIdempotentRequest.
+func (mr *MockServiceMockRecorder) CompleteIdempotentRequest(ctx, userID, key, resp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotentRequest", reflect.TypeOf((*MockService)(nil).CompleteIdempotentRequest), ctx, userID, key, resp)
+}
+
+// CreateCampaign mocks base method.
+func (m *MockService) CreateCampaign(ctx context.Context, req models.CampaignRequest) (*models.CampaignResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCampaign", ctx, req)
+	ret0, _ := ret[0].(*models.CampaignResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCampaign indicates an expected call of CreateCampaign.
+func (mr *MockServiceMockRecorder) CreateCampaign(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCampaign", reflect.TypeOf((*MockService)(nil).CreateCampaign), ctx, req)
+}
+
+// CreateReward mocks base method.
+func (m *MockService) CreateReward(ctx context.Context, req models.RewardRequest) (*models.RewardResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReward", ctx, req)
+	ret0, _ := ret[0].(*models.RewardResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReward indicates an expected call of CreateReward.
+func (mr *MockServiceMockRecorder) CreateReward(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReward", reflect.TypeOf((*MockService)(nil).CreateReward), ctx, req)
+}
+
 // CreateUser mocks base method.
 func (m *MockService) CreateUser(ctx context.Context, userReq models.UserRequest) (int, error) {
 	m.ctrl.T.Helper()
@@ -66,34 +141,156 @@ func (mr *MockServiceMockRecorder) GetBalance(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockService)(nil).GetBalance), ctx)
 }
 
-// GetUserOrders mocks base method.
-func (m *MockService) GetUserOrders(ctx context.Context) ([]models.OrderResponse, error) {
+// GetBalanceHistory mocks base method.
+func (m *MockService) GetBalanceHistory(ctx context.Context, from, to time.Time) ([]models.BalanceHistoryResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserOrders", ctx)
+	ret := m.ctrl.Call(m, "GetBalanceHistory", ctx, from, to)
+	ret0, _ := ret[0].([]models.BalanceHistoryResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceHistory indicates an expected call of GetBalanceHistory.
+func (mr *MockServiceMockRecorder) GetBalanceHistory(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceHistory", reflect.TypeOf((*MockService)(nil).GetBalanceHistory), ctx, from, to)
+}
+
+// GetCampaigns mocks base method.
+func (m *MockService) GetCampaigns(ctx context.Context) ([]models.CampaignResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCampaigns", ctx)
+	ret0, _ := ret[0].([]models.CampaignResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCampaigns indicates an expected call of GetCampaigns.
+func (mr *MockServiceMockRecorder) GetCampaigns(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCampaigns", reflect.TypeOf((*MockService)(nil).GetCampaigns), ctx)
+}
+
+// GetPendingOrders mocks base method.
+func (m *MockService) GetPendingOrders(ctx context.Context) ([]models.OrderResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingOrders", ctx)
 	ret0, _ := ret[0].([]models.OrderResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// GetPendingOrders indicates an expected call of GetPendingOrders.
+func (mr *MockServiceMockRecorder) GetPendingOrders(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingOrders", reflect.TypeOf((*MockService)(nil).GetPendingOrders), ctx)
+}
+
+// GetReferral mocks base method.
+func (m *MockService) GetReferral(ctx context.Context) (*models.ReferralResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReferral", ctx)
+	ret0, _ := ret[0].(*models.ReferralResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferral indicates an expected call of GetReferral.
+func (mr *MockServiceMockRecorder) GetReferral(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferral", reflect.TypeOf((*MockService)(nil).GetReferral), ctx)
+}
+
+// GetRewards mocks base method.
+func (m *MockService) GetRewards(ctx context.Context, all bool) ([]models.RewardResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRewards", ctx, all)
+	ret0, _ := ret[0].([]models.RewardResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRewards indicates an expected call of GetRewards.
+func (mr *MockServiceMockRecorder) GetRewards(ctx, all interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRewards", reflect.TypeOf((*MockService)(nil).GetRewards), ctx, all)
+}
+
+// GetUserOrder mocks base method.
+func (m *MockService) GetUserOrder(ctx context.Context, orderID string) (*models.OrderResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrder", ctx, orderID)
+	ret0, _ := ret[0].(*models.OrderResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserOrder indicates an expected call of GetUserOrder.
+func (mr *MockServiceMockRecorder) GetUserOrder(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrder", reflect.TypeOf((*MockService)(nil).GetUserOrder), ctx, orderID)
+}
+
+// GetUserOrders mocks base method.
+func (m *MockService) GetUserOrders(ctx context.Context, req models.ListRequest) ([]models.OrderResponse, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrders", ctx, req)
+	ret0, _ := ret[0].([]models.OrderResponse)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // GetUserOrders indicates an expected call of GetUserOrders.
-func (mr *MockServiceMockRecorder) GetUserOrders(ctx interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) GetUserOrders(ctx, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrders", reflect.TypeOf((*MockService)(nil).GetUserOrders), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrders", reflect.TypeOf((*MockService)(nil).GetUserOrders), ctx, req)
+}
+
+// GetUserRedemptions mocks base method.
+func (m *MockService) GetUserRedemptions(ctx context.Context) ([]models.RedemptionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRedemptions", ctx)
+	ret0, _ := ret[0].([]models.RedemptionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRedemptions indicates an expected call of GetUserRedemptions.
+func (mr *MockServiceMockRecorder) GetUserRedemptions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRedemptions", reflect.TypeOf((*MockService)(nil).GetUserRedemptions), ctx)
 }
 
 // GetUserWithdrawals mocks base method.
-func (m *MockService) GetUserWithdrawals(ctx context.Context) ([]models.WithdrawalsResponse, error) {
+func (m *MockService) GetUserWithdrawals(ctx context.Context, req models.ListRequest) ([]models.WithdrawalsResponse, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserWithdrawals", ctx)
+	ret := m.ctrl.Call(m, "GetUserWithdrawals", ctx, req)
 	ret0, _ := ret[0].([]models.WithdrawalsResponse)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetUserWithdrawals indicates an expected call of GetUserWithdrawals.
+func (mr *MockServiceMockRecorder) GetUserWithdrawals(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserWithdrawals", reflect.TypeOf((*MockService)(nil).GetUserWithdrawals), ctx, req)
+}
+
+// HoldPoints mocks base method.
+func (m *MockService) HoldPoints(ctx context.Context, req models.HoldRequest) (*models.HoldResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HoldPoints", ctx, req)
+	ret0, _ := ret[0].(*models.HoldResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserWithdrawals indicates an expected call of GetUserWithdrawals.
-func (mr *MockServiceMockRecorder) GetUserWithdrawals(ctx interface{}) *gomock.Call {
+// HoldPoints indicates an expected call of HoldPoints.
+func (mr *MockServiceMockRecorder) HoldPoints(ctx, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserWithdrawals", reflect.TypeOf((*MockService)(nil).GetUserWithdrawals), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HoldPoints", reflect.TypeOf((*MockService)(nil).HoldPoints), ctx, req)
 }
 
 // LoginUser mocks base method.
@@ -125,6 +322,123 @@ func (mr *MockServiceMockRecorder) ProcessOrder(ctx, orderID interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessOrder", reflect.TypeOf((*MockService)(nil).ProcessOrder), ctx, orderID)
 }
 
+// ProcessOrders mocks base method.
+func (m *MockService) ProcessOrders(ctx context.Context, orderIDs []string) ([]models.OrderUploadResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessOrders", ctx, orderIDs)
+	ret0, _ := ret[0].([]models.OrderUploadResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessOrders indicates an expected call of ProcessOrders.
+func (mr *MockServiceMockRecorder) ProcessOrders(ctx, orderIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessOrders", reflect.TypeOf((*MockService)(nil).ProcessOrders), ctx, orderIDs)
+}
+
+// Redeem mocks base method.
+func (m *MockService) Redeem(ctx context.Context, rewardID int) (*models.RedemptionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeem", ctx, rewardID)
+	ret0, _ := ret[0].(*models.RedemptionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Redeem indicates an expected call of Redeem.
+func (mr *MockServiceMockRecorder) Redeem(ctx, rewardID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeem", reflect.TypeOf((*MockService)(nil).Redeem), ctx, rewardID)
+}
+
+// ReleaseHold mocks base method.
+func (m *MockService) ReleaseHold(ctx context.Context, orderID string) (*models.HoldResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseHold", ctx, orderID)
+	ret0, _ := ret[0].(*models.HoldResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseHold indicates an expected call of ReleaseHold.
+func (mr *MockServiceMockRecorder) ReleaseHold(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseHold", reflect.TypeOf((*MockService)(nil).ReleaseHold), ctx, orderID)
+}
+
+// ReleaseIdempotencyKey mocks base method.
+func (m *MockService) ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseIdempotencyKey", ctx, userID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseIdempotencyKey indicates an expected call of ReleaseIdempotencyKey.
+func (mr *MockServiceMockRecorder) ReleaseIdempotencyKey(ctx, userID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdempotencyKey", reflect.TypeOf((*MockService)(nil).ReleaseIdempotencyKey), ctx, userID, key)
+}
+
+// ReverseWithdrawal mocks base method.
+func (m *MockService) ReverseWithdrawal(ctx context.Context, orderID string) (*models.WithdrawalsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseWithdrawal", ctx, orderID)
+	ret0, _ := ret[0].(*models.WithdrawalsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseWithdrawal indicates an expected call of ReverseWithdrawal.
+func (mr *MockServiceMockRecorder) ReverseWithdrawal(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseWithdrawal", reflect.TypeOf((*MockService)(nil).ReverseWithdrawal), ctx, orderID)
+}
+
+// Shutdown mocks base method.
+func (m *MockService) Shutdown(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Shutdown", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Shutdown indicates an expected call of Shutdown.
+func (mr *MockServiceMockRecorder) Shutdown(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockService)(nil).Shutdown), ctx)
+}
+
+// Transfer mocks base method.
+func (m *MockService) Transfer(ctx context.Context, req models.TransferRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockServiceMockRecorder) Transfer(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockService)(nil).Transfer), ctx, req)
+}
+
+// UpdateReward mocks base method.
+func (m *MockService) UpdateReward(ctx context.Context, rewardID int, req models.RewardRequest) (*models.RewardResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateReward", ctx, rewardID, req)
+	ret0, _ := ret[0].(*models.RewardResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateReward indicates an expected call of UpdateReward.
+func (mr *MockServiceMockRecorder) UpdateReward(ctx, rewardID, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateReward", reflect.TypeOf((*MockService)(nil).UpdateReward), ctx, rewardID, req)
+}
+
 // Withdraw mocks base method.
 func (m *MockService) Withdraw(ctx context.Context, req models.WithdrawRequest) error {
 	m.ctrl.T.Helper()
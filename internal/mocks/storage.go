@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 
@@ -36,6 +37,124 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// BeginIdempotentRequest mocks base method.
+func (m *MockRepository) BeginIdempotentRequest(ctx context.Context, rec entity.IdempotencyRecord) (*entity.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginIdempotentRequest", ctx, rec)
+	ret0, _ := ret[0].(*entity.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginIdempotentRequest indicates an expected call of BeginIdempotentRequest.
+func (mr *MockRepositoryMockRecorder) BeginIdempotentRequest(ctx, rec interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginIdempotentRequest", reflect.TypeOf((*MockRepository)(nil).BeginIdempotentRequest), ctx, rec)
+}
+
+// CaptureHold mocks base method.
+func (m *MockRepository) CaptureHold(ctx context.Context, userID int, orderID string) (*entity.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureHold", ctx, userID, orderID)
+	ret0, _ := ret[0].(*entity.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaptureHold indicates an expected call of CaptureHold.
+func (mr *MockRepositoryMockRecorder) CaptureHold(ctx, userID, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureHold", reflect.TypeOf((*MockRepository)(nil).CaptureHold), ctx, userID, orderID)
+}
+
+// ClaimAccrualJobs mocks base method.
+func (m *MockRepository) ClaimAccrualJobs(ctx context.Context, limit int, lease time.Duration) ([]entity.AccrualJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimAccrualJobs", ctx, limit, lease)
+	ret0, _ := ret[0].([]entity.AccrualJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimAccrualJobs indicates an expected call of ClaimAccrualJobs.
+func (mr *MockRepositoryMockRecorder) ClaimAccrualJobs(ctx, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimAccrualJobs", reflect.TypeOf((*MockRepository)(nil).ClaimAccrualJobs), ctx, limit, lease)
+}
+
+// CompleteAccrualJob mocks base method.
+func (m *MockRepository) CompleteAccrualJob(ctx context.Context, orderID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteAccrualJob", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteAccrualJob indicates an expected call of CompleteAccrualJob.
+func (mr *MockRepositoryMockRecorder) CompleteAccrualJob(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteAccrualJob", reflect.TypeOf((*MockRepository)(nil).CompleteAccrualJob), ctx, orderID)
+}
+
+// CompleteIdempotentRequest mocks base method.
+func (m *MockRepository) CompleteIdempotentRequest(ctx context.Context, rec entity.IdempotencyRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteIdempotentRequest", ctx, rec)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteIdempotentRequest indicates an expected call of CompleteIdempotentRequest.
+func (mr *MockRepositoryMockRecorder) CompleteIdempotentRequest(ctx, rec interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotentRequest", reflect.TypeOf((*MockRepository)(nil).CompleteIdempotentRequest), ctx, rec)
+}
+
+// CountCreditedOrders mocks base method.
+func (m *MockRepository) CountCreditedOrders(ctx context.Context, userID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountCreditedOrders", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountCreditedOrders indicates an expected call of CountCreditedOrders.
+func (mr *MockRepositoryMockRecorder) CountCreditedOrders(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountCreditedOrders", reflect.TypeOf((*MockRepository)(nil).CountCreditedOrders), ctx, userID)
+}
+
+// CountPendingAccrualJobs mocks base method.
+func (m *MockRepository) CountPendingAccrualJobs(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPendingAccrualJobs", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPendingAccrualJobs indicates an expected call of CountPendingAccrualJobs.
+func (mr *MockRepositoryMockRecorder) CountPendingAccrualJobs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPendingAccrualJobs", reflect.TypeOf((*MockRepository)(nil).CountPendingAccrualJobs), ctx)
+}
+
+// CountUserOrdersByStatus mocks base method.
+func (m *MockRepository) CountUserOrdersByStatus(ctx context.Context, userID int, statuses []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUserOrdersByStatus", ctx, userID, statuses)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUserOrdersByStatus indicates an expected call of CountUserOrdersByStatus.
+func (mr *MockRepositoryMockRecorder) CountUserOrdersByStatus(ctx, userID, statuses interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUserOrdersByStatus", reflect.TypeOf((*MockRepository)(nil).CountUserOrdersByStatus), ctx, userID, statuses)
+}
+
 // CreateBalance mocks base method.
 func (m *MockRepository) CreateBalance(ctx context.Context, userID int) error {
 	m.ctrl.T.Helper()
@@ -50,6 +169,94 @@ func (mr *MockRepositoryMockRecorder) CreateBalance(ctx, userID interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBalance", reflect.TypeOf((*MockRepository)(nil).CreateBalance), ctx, userID)
 }
 
+// CreditOrderAccrual mocks base method.
+func (m *MockRepository) CreditOrderAccrual(ctx context.Context, order entity.Order, credit entity.OrderCredit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreditOrderAccrual", ctx, order, credit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreditOrderAccrual indicates an expected call of CreditOrderAccrual.
+func (mr *MockRepositoryMockRecorder) CreditOrderAccrual(ctx, order, credit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreditOrderAccrual", reflect.TypeOf((*MockRepository)(nil).CreditOrderAccrual), ctx, order, credit)
+}
+
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockRepositoryMockRecorder) DeleteExpiredIdempotencyKeys(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockRepository)(nil).DeleteExpiredIdempotencyKeys), ctx, limit)
+}
+
+// EnqueueAccrualJobs mocks base method.
+func (m *MockRepository) EnqueueAccrualJobs(ctx context.Context, orders []entity.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueAccrualJobs", ctx, orders)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnqueueAccrualJobs indicates an expected call of EnqueueAccrualJobs.
+func (mr *MockRepositoryMockRecorder) EnqueueAccrualJobs(ctx, orders interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueAccrualJobs", reflect.TypeOf((*MockRepository)(nil).EnqueueAccrualJobs), ctx, orders)
+}
+
+// ExpireHolds mocks base method.
+func (m *MockRepository) ExpireHolds(ctx context.Context, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireHolds", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireHolds indicates an expected call of ExpireHolds.
+func (mr *MockRepositoryMockRecorder) ExpireHolds(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHolds", reflect.TypeOf((*MockRepository)(nil).ExpireHolds), ctx, limit)
+}
+
+// ExpirePoints mocks base method.
+func (m *MockRepository) ExpirePoints(ctx context.Context, ttl time.Duration, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpirePoints", ctx, ttl, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpirePoints indicates an expected call of ExpirePoints.
+func (mr *MockRepositoryMockRecorder) ExpirePoints(ctx, ttl, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpirePoints", reflect.TypeOf((*MockRepository)(nil).ExpirePoints), ctx, ttl, limit)
+}
+
+// GetActiveCampaigns mocks base method.
+func (m *MockRepository) GetActiveCampaigns(ctx context.Context) ([]entity.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveCampaigns", ctx)
+	ret0, _ := ret[0].([]entity.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveCampaigns indicates an expected call of GetActiveCampaigns.
+func (mr *MockRepositoryMockRecorder) GetActiveCampaigns(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveCampaigns", reflect.TypeOf((*MockRepository)(nil).GetActiveCampaigns), ctx)
+}
+
 // GetBalance mocks base method.
 func (m *MockRepository) GetBalance(ctx context.Context, userID int) (*entity.Balance, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +272,66 @@ func (mr *MockRepositoryMockRecorder) GetBalance(ctx, userID interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockRepository)(nil).GetBalance), ctx, userID)
 }
 
+// GetBalanceHistory mocks base method.
+func (m *MockRepository) GetBalanceHistory(ctx context.Context, userID int, from, to time.Time) ([]entity.LedgerEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceHistory", ctx, userID, from, to)
+	ret0, _ := ret[0].([]entity.LedgerEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceHistory indicates an expected call of GetBalanceHistory.
+func (mr *MockRepositoryMockRecorder) GetBalanceHistory(ctx, userID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceHistory", reflect.TypeOf((*MockRepository)(nil).GetBalanceHistory), ctx, userID, from, to)
+}
+
+// GetCampaigns mocks base method.
+func (m *MockRepository) GetCampaigns(ctx context.Context) ([]entity.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCampaigns", ctx)
+	ret0, _ := ret[0].([]entity.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCampaigns indicates an expected call of GetCampaigns.
+func (mr *MockRepositoryMockRecorder) GetCampaigns(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCampaigns", reflect.TypeOf((*MockRepository)(nil).GetCampaigns), ctx)
+}
+
+// GetExpiringPoints mocks base method.
+func (m *MockRepository) GetExpiringPoints(ctx context.Context, userID int, age time.Duration) (*entity.ExpiringPoints, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiringPoints", ctx, userID, age)
+	ret0, _ := ret[0].(*entity.ExpiringPoints)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiringPoints indicates an expected call of GetExpiringPoints.
+func (mr *MockRepositoryMockRecorder) GetExpiringPoints(ctx, userID, age interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiringPoints", reflect.TypeOf((*MockRepository)(nil).GetExpiringPoints), ctx, userID, age)
+}
+
+// GetLifetimeAccrual mocks base method.
+func (m *MockRepository) GetLifetimeAccrual(ctx context.Context, userID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLifetimeAccrual", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLifetimeAccrual indicates an expected call of GetLifetimeAccrual.
+func (mr *MockRepositoryMockRecorder) GetLifetimeAccrual(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLifetimeAccrual", reflect.TypeOf((*MockRepository)(nil).GetLifetimeAccrual), ctx, userID)
+}
+
 // GetOrder mocks base method.
 func (m *MockRepository) GetOrder(ctx context.Context, orderID string) (*entity.Order, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +347,51 @@ func (mr *MockRepositoryMockRecorder) GetOrder(ctx, orderID interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockRepository)(nil).GetOrder), ctx, orderID)
 }
 
+// GetOrdersByStatus mocks base method.
+func (m *MockRepository) GetOrdersByStatus(ctx context.Context, statuses []string, afterOrderID string, limit int) ([]entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersByStatus", ctx, statuses, afterOrderID, limit)
+	ret0, _ := ret[0].([]entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersByStatus indicates an expected call of GetOrdersByStatus.
+func (mr *MockRepositoryMockRecorder) GetOrdersByStatus(ctx, statuses, afterOrderID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByStatus", reflect.TypeOf((*MockRepository)(nil).GetOrdersByStatus), ctx, statuses, afterOrderID, limit)
+}
+
+// GetReferralStats mocks base method.
+func (m *MockRepository) GetReferralStats(ctx context.Context, userID int) (*entity.ReferralStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReferralStats", ctx, userID)
+	ret0, _ := ret[0].(*entity.ReferralStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferralStats indicates an expected call of GetReferralStats.
+func (mr *MockRepositoryMockRecorder) GetReferralStats(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferralStats", reflect.TypeOf((*MockRepository)(nil).GetReferralStats), ctx, userID)
+}
+
+// GetRewards mocks base method.
+func (m *MockRepository) GetRewards(ctx context.Context, all bool) ([]entity.Reward, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRewards", ctx, all)
+	ret0, _ := ret[0].([]entity.Reward)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRewards indicates an expected call of GetRewards.
+func (mr *MockRepositoryMockRecorder) GetRewards(ctx, all interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRewards", reflect.TypeOf((*MockRepository)(nil).GetRewards), ctx, all)
+}
+
 // GetUser mocks base method.
 func (m *MockRepository) GetUser(ctx context.Context, login string) (*entity.User, error) {
 	m.ctrl.T.Helper()
@@ -95,34 +407,182 @@ func (mr *MockRepositoryMockRecorder) GetUser(ctx, login interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockRepository)(nil).GetUser), ctx, login)
 }
 
+// GetUserByReferralCode mocks base method.
+func (m *MockRepository) GetUserByReferralCode(ctx context.Context, code string) (*entity.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByReferralCode", ctx, code)
+	ret0, _ := ret[0].(*entity.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByReferralCode indicates an expected call of GetUserByReferralCode.
+func (mr *MockRepositoryMockRecorder) GetUserByReferralCode(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByReferralCode", reflect.TypeOf((*MockRepository)(nil).GetUserByReferralCode), ctx, code)
+}
+
 // GetUserOrders mocks base method.
-func (m *MockRepository) GetUserOrders(ctx context.Context, userID int) ([]entity.Order, error) {
+func (m *MockRepository) GetUserOrders(ctx context.Context, userID int, filter entity.OrderFilter) ([]entity.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserOrders", ctx, userID)
+	ret := m.ctrl.Call(m, "GetUserOrders", ctx, userID, filter)
 	ret0, _ := ret[0].([]entity.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserOrders indicates an expected call of GetUserOrders.
-func (mr *MockRepositoryMockRecorder) GetUserOrders(ctx, userID interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetUserOrders(ctx, userID, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrders", reflect.TypeOf((*MockRepository)(nil).GetUserOrders), ctx, userID, filter)
+}
+
+// GetUserOrdersByStatus mocks base method.
+func (m *MockRepository) GetUserOrdersByStatus(ctx context.Context, userID int, statuses []string) ([]entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrdersByStatus", ctx, userID, statuses)
+	ret0, _ := ret[0].([]entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserOrdersByStatus indicates an expected call of GetUserOrdersByStatus.
+func (mr *MockRepositoryMockRecorder) GetUserOrdersByStatus(ctx, userID, statuses interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrders", reflect.TypeOf((*MockRepository)(nil).GetUserOrders), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrdersByStatus", reflect.TypeOf((*MockRepository)(nil).GetUserOrdersByStatus), ctx, userID, statuses)
+}
+
+// GetUserRedemptions mocks base method.
+func (m *MockRepository) GetUserRedemptions(ctx context.Context, userID int) ([]entity.Redemption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRedemptions", ctx, userID)
+	ret0, _ := ret[0].([]entity.Redemption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRedemptions indicates an expected call of GetUserRedemptions.
+func (mr *MockRepositoryMockRecorder) GetUserRedemptions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRedemptions", reflect.TypeOf((*MockRepository)(nil).GetUserRedemptions), ctx, userID)
 }
 
 // GetUserWithdrawals mocks base method.
-func (m *MockRepository) GetUserWithdrawals(ctx context.Context, userID int) ([]entity.Withdraw, error) {
+func (m *MockRepository) GetUserWithdrawals(ctx context.Context, userID int, filter entity.WithdrawalFilter) ([]entity.Withdraw, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserWithdrawals", ctx, userID)
+	ret := m.ctrl.Call(m, "GetUserWithdrawals", ctx, userID, filter)
 	ret0, _ := ret[0].([]entity.Withdraw)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserWithdrawals indicates an expected call of GetUserWithdrawals.
-func (mr *MockRepositoryMockRecorder) GetUserWithdrawals(ctx, userID interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetUserWithdrawals(ctx, userID, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserWithdrawals", reflect.TypeOf((*MockRepository)(nil).GetUserWithdrawals), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserWithdrawals", reflect.TypeOf((*MockRepository)(nil).GetUserWithdrawals), ctx, userID, filter)
+}
+
+// HoldPoints mocks base method.
+func (m *MockRepository) HoldPoints(ctx context.Context, h entity.Hold, ttl time.Duration) (*entity.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HoldPoints", ctx, h, ttl)
+	ret0, _ := ret[0].(*entity.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HoldPoints indicates an expected call of HoldPoints.
+func (mr *MockRepositoryMockRecorder) HoldPoints(ctx, h, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HoldPoints", reflect.TypeOf((*MockRepository)(nil).HoldPoints), ctx, h, ttl)
+}
+
+// Redeem mocks base method.
+func (m *MockRepository) Redeem(ctx context.Context, userID, rewardID int) (*entity.Redemption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeem", ctx, userID, rewardID)
+	ret0, _ := ret[0].(*entity.Redemption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Redeem indicates an expected call of Redeem.
+func (mr *MockRepositoryMockRecorder) Redeem(ctx, userID, rewardID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeem", reflect.TypeOf((*MockRepository)(nil).Redeem), ctx, userID, rewardID)
+}
+
+// ReleaseHold mocks base method.
+func (m *MockRepository) ReleaseHold(ctx context.Context, userID int, orderID string) (*entity.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseHold", ctx, userID, orderID)
+	ret0, _ := ret[0].(*entity.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseHold indicates an expected call of ReleaseHold.
+func (mr *MockRepositoryMockRecorder) ReleaseHold(ctx, userID, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseHold", reflect.TypeOf((*MockRepository)(nil).ReleaseHold), ctx, userID, orderID)
+}
+
+// ReleaseIdempotencyKey mocks base method.
+func (m *MockRepository) ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseIdempotencyKey", ctx, userID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseIdempotencyKey indicates an expected call of ReleaseIdempotencyKey.
+func (mr *MockRepositoryMockRecorder) ReleaseIdempotencyKey(ctx, userID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdempotencyKey", reflect.TypeOf((*MockRepository)(nil).ReleaseIdempotencyKey), ctx, userID, key)
+}
+
+// RescheduleAccrualJob mocks base method.
+func (m *MockRepository) RescheduleAccrualJob(ctx context.Context, orderID string, delay time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleAccrualJob", ctx, orderID, delay)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RescheduleAccrualJob indicates an expected call of RescheduleAccrualJob.
+func (mr *MockRepositoryMockRecorder) RescheduleAccrualJob(ctx, orderID, delay interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleAccrualJob", reflect.TypeOf((*MockRepository)(nil).RescheduleAccrualJob), ctx, orderID, delay)
+}
+
+// ReverseWithdrawal mocks base method.
+func (m *MockRepository) ReverseWithdrawal(ctx context.Context, orderID string) (*entity.Withdraw, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseWithdrawal", ctx, orderID)
+	ret0, _ := ret[0].(*entity.Withdraw)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseWithdrawal indicates an expected call of ReverseWithdrawal.
+func (mr *MockRepositoryMockRecorder) ReverseWithdrawal(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseWithdrawal", reflect.TypeOf((*MockRepository)(nil).ReverseWithdrawal), ctx, orderID)
+}
+
+// SaveCampaign mocks base method.
+func (m *MockRepository) SaveCampaign(ctx context.Context, c entity.Campaign) (*entity.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCampaign", ctx, c)
+	ret0, _ := ret[0].(*entity.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveCampaign indicates an expected call of SaveCampaign.
+func (mr *MockRepositoryMockRecorder) SaveCampaign(ctx, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCampaign", reflect.TypeOf((*MockRepository)(nil).SaveCampaign), ctx, c)
 }
 
 // SaveOrder mocks base method.
@@ -139,6 +599,36 @@ func (mr *MockRepositoryMockRecorder) SaveOrder(ctx, order interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOrder", reflect.TypeOf((*MockRepository)(nil).SaveOrder), ctx, order)
 }
 
+// SaveOrders mocks base method.
+func (m *MockRepository) SaveOrders(ctx context.Context, userID int, orderIDs []string, status string) ([]entity.OrderUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveOrders", ctx, userID, orderIDs, status)
+	ret0, _ := ret[0].([]entity.OrderUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveOrders indicates an expected call of SaveOrders.
+func (mr *MockRepositoryMockRecorder) SaveOrders(ctx, userID, orderIDs, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOrders", reflect.TypeOf((*MockRepository)(nil).SaveOrders), ctx, userID, orderIDs, status)
+}
+
+// SaveReward mocks base method.
+func (m *MockRepository) SaveReward(ctx context.Context, r entity.Reward) (*entity.Reward, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveReward", ctx, r)
+	ret0, _ := ret[0].(*entity.Reward)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveReward indicates an expected call of SaveReward.
+func (mr *MockRepositoryMockRecorder) SaveReward(ctx, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveReward", reflect.TypeOf((*MockRepository)(nil).SaveReward), ctx, r)
+}
+
 // SaveUser mocks base method.
 func (m *MockRepository) SaveUser(ctx context.Context, user entity.User) (int, error) {
 	m.ctrl.T.Helper()
@@ -154,18 +644,18 @@ func (mr *MockRepositoryMockRecorder) SaveUser(ctx, user interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUser", reflect.TypeOf((*MockRepository)(nil).SaveUser), ctx, user)
 }
 
-// UpdateBalance mocks base method.
-func (m *MockRepository) UpdateBalance(amount, userID int) error {
+// Transfer mocks base method.
+func (m *MockRepository) Transfer(ctx context.Context, t entity.Transfer, dailyLimit int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateBalance", amount, userID)
+	ret := m.ctrl.Call(m, "Transfer", ctx, t, dailyLimit)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateBalance indicates an expected call of UpdateBalance.
-func (mr *MockRepositoryMockRecorder) UpdateBalance(amount, userID interface{}) *gomock.Call {
+// Transfer indicates an expected call of Transfer.
+func (mr *MockRepositoryMockRecorder) Transfer(ctx, t, dailyLimit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBalance", reflect.TypeOf((*MockRepository)(nil).UpdateBalance), amount, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockRepository)(nil).Transfer), ctx, t, dailyLimit)
 }
 
 // UpdateOrder mocks base method.
@@ -182,6 +672,21 @@ func (mr *MockRepositoryMockRecorder) UpdateOrder(order interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrder", reflect.TypeOf((*MockRepository)(nil).UpdateOrder), order)
 }
 
+// UpdateReward mocks base method.
+func (m *MockRepository) UpdateReward(ctx context.Context, r entity.Reward) (*entity.Reward, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateReward", ctx, r)
+	ret0, _ := ret[0].(*entity.Reward)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateReward indicates an expected call of UpdateReward.
+func (mr *MockRepositoryMockRecorder) UpdateReward(ctx, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateReward", reflect.TypeOf((*MockRepository)(nil).UpdateReward), ctx, r)
+}
+
 // Withdraw mocks base method.
 func (m *MockRepository) Withdraw(ctx context.Context, w entity.Withdraw) error {
 	m.ctrl.T.Helper()
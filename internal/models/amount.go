@@ -0,0 +1,77 @@
+package models
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+const amountPrecision = 2
+
+// maxAmount is the largest amount in hundredths the storage holds; balances
+// and sums are kept in INT columns.
+const maxAmount = math.MaxInt32
+
+var (
+	ErrAmountNotNumber = errors.New("amount must be a JSON number")
+	ErrAmountSyntax    = errors.New("amount must be a decimal number")
+	ErrAmountPrecision = errors.New("amount must have at most two fractional digits")
+	ErrAmountRange     = errors.New("amount must be from -21474836.47 to 21474836.47")
+)
+
+// Amount is a number of points kept in hundredths, so it never goes through
+// floating point. In JSON it is a plain number such as 729.98.
+type Amount int64
+
+func (a Amount) String() string {
+	return decimal.New(int64(a), -amountPrecision).String()
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		return ErrAmountNotNumber
+	}
+
+	d, err := decimal.NewFromString(string(data))
+	if err != nil {
+		return ErrAmountNotNumber
+	}
+
+	return a.setDecimal(d)
+}
+
+// Set parses a decimal number of points such as 1000 or 99.5, which makes
+// *Amount usable as a command line flag.
+func (a *Amount) Set(s string) error {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return ErrAmountSyntax
+	}
+
+	return a.setDecimal(d)
+}
+
+func (a *Amount) setDecimal(d decimal.Decimal) error {
+	if !d.Equal(d.Truncate(amountPrecision)) {
+		return ErrAmountPrecision
+	}
+
+	hundredths := d.Shift(amountPrecision)
+	if hundredths.Abs().GreaterThan(decimal.NewFromInt(maxAmount)) {
+		return ErrAmountRange
+	}
+
+	*a = Amount(hundredths.IntPart())
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAmount_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Amount
+		want   string
+	}{
+		{
+			name:   "should marshal whole amount without fraction",
+			amount: 50000,
+			want:   "500",
+		},
+		{
+			name:   "should marshal amount with fraction",
+			amount: 72998,
+			want:   "729.98",
+		},
+		{
+			name:   "should marshal zero",
+			amount: 0,
+			want:   "0",
+		},
+		{
+			name:   "should marshal negative amount",
+			amount: -29,
+			want:   "-0.29",
+		},
+		{
+			name:   "should marshal large amount",
+			amount: 123456789,
+			want:   "1234567.89",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := json.Marshal(tt.amount)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(result))
+		})
+	}
+}
+
+func TestAmount_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Amount
+		wantErr error
+	}{
+		{
+			name: "should parse whole number",
+			data: "500",
+			want: 50000,
+		},
+		{
+			name: "should parse amount that is not exact in float",
+			data: "0.29",
+			want: 29,
+		},
+		{
+			name: "should parse amount with two fractional digits",
+			data: "729.98",
+			want: 72998,
+		},
+		{
+			name: "should parse trailing zeros",
+			data: "1.500",
+			want: 150,
+		},
+		{
+			name: "should parse negative amount",
+			data: "-1",
+			want: -100,
+		},
+		{
+			name:    "should reject more than two fractional digits",
+			data:    "0.295",
+			wantErr: ErrAmountPrecision,
+		},
+		{
+			name:    "should reject string",
+			data:    `"12"`,
+			wantErr: ErrAmountNotNumber,
+		},
+		{
+			name:    "should reject amount that overflows int64",
+			data:    "184467440737095516.17",
+			wantErr: ErrAmountRange,
+		},
+		{
+			name:    "should reject amount above storage range",
+			data:    "21474836.48",
+			wantErr: ErrAmountRange,
+		},
+		{
+			name:    "should reject amount below storage range",
+			data:    "-21474836.48",
+			wantErr: ErrAmountRange,
+		},
+		{
+			name: "should parse largest amount",
+			data: "21474836.47",
+			want: math.MaxInt32,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result Amount
+			err := json.Unmarshal([]byte(tt.data), &result)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func TestAmount_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Amount
+		wantErr error
+	}{
+		{
+			name:  "should parse whole number",
+			value: "1000",
+			want:  100000,
+		},
+		{
+			name:  "should parse number with fraction",
+			value: "99.5",
+			want:  9950,
+		},
+		{
+			name:    "should reject non-number",
+			value:   "ten",
+			wantErr: ErrAmountSyntax,
+		},
+		{
+			name:    "should reject more than two fractional digits",
+			value:   "1.005",
+			wantErr: ErrAmountPrecision,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a Amount
+
+			err := a.Set(tt.value)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, a)
+		})
+	}
+}
@@ -1,35 +1,194 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/PrahaTurbo/gophermart/internal/validator"
+)
+
+// UserRequest is the user to register or log in. ReferralCode is optional
+// and only used at registration.
 type UserRequest struct {
-	Login    string `json:"login"`
-	Password string `json:"password"`
+	Login        string `json:"login"`
+	Password     string `json:"password"`
+	ReferralCode string `json:"referral_code,omitempty"`
+}
+
+// ReferralResponse is the user's referral code along with how many users
+// registered with it and how many of them brought the user a bonus.
+type ReferralResponse struct {
+	Code     string `json:"code"`
+	Referred int    `json:"referred"`
+	Rewarded int    `json:"rewarded"`
+}
+
+type OrderRequest struct {
+	Number string
+}
+
+// OrderUploadResult is the outcome of one order number of a batch upload.
+type OrderUploadResult struct {
+	Number string `json:"number"`
+	Status string `json:"status"`
 }
 
 type OrderResponse struct {
-	ID         string  `json:"number"`
-	Accrual    float64 `json:"accrual"`
-	Status     string  `json:"status"`
-	UploadedAt string  `json:"uploaded_at"`
+	ID         string `json:"number"`
+	Accrual    Amount `json:"accrual"`
+	Status     string `json:"status"`
+	UploadedAt string `json:"uploaded_at"`
+	CreditedAt string `json:"credited_at,omitempty"`
 }
 
 type AccrualResponse struct {
-	Order   string  `json:"order"`
-	Status  string  `json:"status"`
-	Accrual float64 `json:"accrual"`
+	Order   string `json:"order"`
+	Status  string `json:"status"`
+	Accrual Amount `json:"accrual"`
 }
 
+// BalanceResponse is the user's balance. Pending is the number of orders
+// whose accrual is not known yet, as the accrual system reports it only once
+// an order is processed. ExpiringSoon points expire soon, the first of them
+// at ExpiresAt. Tier is the user's loyalty tier, empty when tiers are off.
 type BalanceResponse struct {
-	Current   float64 `json:"current"`
-	Withdrawn float64 `json:"withdrawn"`
+	Current      Amount `json:"current"`
+	Reserved     Amount `json:"reserved"`
+	Withdrawn    Amount `json:"withdrawn"`
+	Pending      int    `json:"pending"`
+	ExpiringSoon Amount `json:"expiring_soon"`
+	ExpiresAt    string `json:"expires_at,omitempty"`
+	Tier         string `json:"tier,omitempty"`
+}
+
+// ListRequest selects a page of a list. Cursor is the one returned with the
+// previous page, empty for the first page. Zero Limit asks for the whole list
+// and is only allowed without Cursor. Statuses, From and To filter the
+// list and Desc sorts it newest first.
+type ListRequest struct {
+	Limit    int
+	Cursor   string
+	Statuses []string
+	From     time.Time
+	To       time.Time
+	Desc     bool
+}
+
+type BalanceHistoryRequest struct {
+	From time.Time
+	To   time.Time
+}
+
+type BalanceHistoryResponse struct {
+	Type      string `json:"type"`
+	Reference string `json:"reference,omitempty"`
+	Amount    Amount `json:"amount"`
+	Balance   Amount `json:"balance"`
+	CreatedAt string `json:"created_at"`
 }
 
 type WithdrawRequest struct {
-	Order string  `json:"order"`
-	Sum   float64 `json:"sum"`
+	Order string `json:"order"`
+	Sum   Amount `json:"sum"`
 }
 
 type WithdrawalsResponse struct {
-	Order       string  `json:"order"`
-	Sum         float64 `json:"sum"`
-	ProcessedAt string  `json:"processed_at"`
+	Order       string `json:"order"`
+	Sum         Amount `json:"sum"`
+	Status      string `json:"status"`
+	ProcessedAt string `json:"processed_at"`
+	ReversedAt  string `json:"reversed_at,omitempty"`
+}
+
+type TransferRequest struct {
+	Login string `json:"login"`
+	Sum   Amount `json:"sum"`
+}
+
+type HoldRequest struct {
+	Order string `json:"order"`
+	Sum   Amount `json:"sum"`
+}
+
+type HoldResponse struct {
+	Order      string `json:"order"`
+	Sum        Amount `json:"sum"`
+	Status     string `json:"status"`
+	CreatedAt  string `json:"created_at"`
+	ExpiresAt  string `json:"expires_at"`
+	ResolvedAt string `json:"resolved_at,omitempty"`
+}
+
+// IdempotentResponse is a response stored for replaying to requests repeated
+// with the same idempotency key.
+type IdempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+type ErrorResponse struct {
+	Errors validator.Errors `json:"errors"`
+}
+
+// CampaignRequest describes a campaign to create. Multiplier defaults to 1,
+// so a campaign can give a fixed Bonus only.
+type CampaignRequest struct {
+	Name           string      `json:"name"`
+	Multiplier     json.Number `json:"multiplier,omitempty"`
+	Bonus          Amount      `json:"bonus"`
+	MinAccrual     Amount      `json:"min_accrual"`
+	FirstOrderOnly bool        `json:"first_order_only"`
+	StartsAt       time.Time   `json:"starts_at"`
+	EndsAt         time.Time   `json:"ends_at"`
+}
+
+// ParseMultiplier returns the campaign multiplier, 1 if it is not set.
+func (r CampaignRequest) ParseMultiplier() (decimal.Decimal, error) {
+	if r.Multiplier == "" {
+		return decimal.NewFromInt(1), nil
+	}
+
+	return decimal.NewFromString(r.Multiplier.String())
+}
+
+type CampaignResponse struct {
+	ID             int         `json:"id"`
+	Name           string      `json:"name"`
+	Multiplier     json.Number `json:"multiplier"`
+	Bonus          Amount      `json:"bonus"`
+	MinAccrual     Amount      `json:"min_accrual"`
+	FirstOrderOnly bool        `json:"first_order_only"`
+	StartsAt       string      `json:"starts_at"`
+	EndsAt         string      `json:"ends_at"`
+	CreatedAt      string      `json:"created_at"`
+}
+
+// RewardRequest describes a reward of the catalog. A reward is active unless
+// Active is set to false.
+type RewardRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       Amount `json:"price"`
+	Stock       int    `json:"stock"`
+	Active      *bool  `json:"active,omitempty"`
+}
+
+type RewardResponse struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Price       Amount `json:"price"`
+	Stock       int    `json:"stock"`
+	Active      bool   `json:"active"`
+}
+
+type RedemptionResponse struct {
+	ID         int    `json:"id"`
+	RewardID   int    `json:"reward_id"`
+	Name       string `json:"name"`
+	Price      Amount `json:"price"`
+	RedeemedAt string `json:"redeemed_at"`
 }
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/PrahaTurbo/gophermart/internal/validator"
+)
+
+const (
+	msgRequired    = "must not be empty"
+	msgOrderNumber = "must be a valid order number"
+	msgPositive    = "must be greater than zero"
+	msgNegative    = "must not be negative"
+)
+
+// MaxListLimit is the largest page a list can be requested in.
+const MaxListLimit = 1000
+
+const (
+	maxMultiplier      = 10000
+	maxReferralCodeLen = 16
+)
+
+func (r UserRequest) Validate() error {
+	v := validator.New()
+	v.Check(validator.NotBlank(r.Login), "login", msgRequired)
+	v.Check(validator.NotBlank(r.Password), "password", msgRequired)
+	v.Check(len(r.ReferralCode) <= maxReferralCodeLen, "referral_code", "must be a valid referral code")
+
+	return v.Err()
+}
+
+func (r OrderRequest) Validate() error {
+	v := validator.New()
+	v.Check(validator.Luhn(r.Number), "number", msgOrderNumber)
+
+	return v.Err()
+}
+
+func (r WithdrawRequest) Validate() error {
+	v := validator.New()
+	v.Check(validator.Luhn(r.Order), "order", msgOrderNumber)
+	v.Check(r.Sum > 0, "sum", msgPositive)
+
+	return v.Err()
+}
+
+func (r TransferRequest) Validate() error {
+	v := validator.New()
+	v.Check(validator.NotBlank(r.Login), "login", msgRequired)
+	v.Check(r.Sum > 0, "sum", msgPositive)
+
+	return v.Err()
+}
+
+func (r HoldRequest) Validate() error {
+	v := validator.New()
+	v.Check(validator.Luhn(r.Order), "order", msgOrderNumber)
+	v.Check(r.Sum > 0, "sum", msgPositive)
+
+	return v.Err()
+}
+
+func (r CampaignRequest) Validate() error {
+	v := validator.New()
+	v.Check(validator.NotBlank(r.Name), "name", msgRequired)
+
+	one := decimal.NewFromInt(1)
+	multiplier, err := r.ParseMultiplier()
+	v.Check(err == nil && multiplier.GreaterThanOrEqual(one) && multiplier.LessThan(decimal.NewFromInt(maxMultiplier)),
+		"multiplier", "must be a number from 1 to 9999.99")
+	v.Check(err != nil || multiplier.Equal(multiplier.Truncate(2)), "multiplier", "must have at most two fractional digits")
+	v.Check(r.Bonus >= 0, "bonus", msgNegative)
+	v.Check(r.MinAccrual >= 0, "min_accrual", msgNegative)
+	v.Check(err != nil || multiplier.GreaterThan(one) || r.Bonus > 0, "bonus", "must be greater than zero unless multiplier is above 1")
+	v.Check(!r.StartsAt.IsZero(), "starts_at", msgRequired)
+	v.Check(r.EndsAt.After(r.StartsAt), "ends_at", "must be after starts_at")
+
+	return v.Err()
+}
+
+func (r RewardRequest) Validate() error {
+	v := validator.New()
+	v.Check(validator.NotBlank(r.Name), "name", msgRequired)
+	v.Check(r.Price > 0, "price", msgPositive)
+	v.Check(r.Stock >= 0, "stock", msgNegative)
+
+	return v.Err()
+}
+
+func (r ListRequest) Validate() error {
+	v := validator.New()
+	v.Check(r.Limit == 0 && r.Cursor == "" || r.Limit > 0 && r.Limit <= MaxListLimit, "limit", "must be from 1 to 1000")
+	v.Check(r.To.IsZero() || r.From.Before(r.To), "to", "must be after from")
+
+	return v.Err()
+}
+
+func (r BalanceHistoryRequest) Validate() error {
+	v := validator.New()
+	v.Check(r.To.IsZero() || r.From.Before(r.To), "to", "must be after from")
+
+	return v.Err()
+}
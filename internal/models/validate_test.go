@@ -0,0 +1,48 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PrahaTurbo/gophermart/internal/validator"
+)
+
+func TestWithdrawRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  WithdrawRequest
+		want error
+	}{
+		{
+			name: "should accept valid request",
+			req:  WithdrawRequest{Order: "12345678903", Sum: 1},
+		},
+		{
+			name: "should reject zero sum",
+			req:  WithdrawRequest{Order: "12345678903", Sum: 0},
+			want: validator.Errors{{Field: "sum", Message: msgPositive}},
+		},
+		{
+			name: "should reject negative sum and invalid order",
+			req:  WithdrawRequest{Order: "12a", Sum: -100},
+			want: validator.Errors{
+				{Field: "order", Message: msgOrderNumber},
+				{Field: "sum", Message: msgPositive},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.req.Validate())
+		})
+	}
+}
+
+func TestUserRequest_Validate(t *testing.T) {
+	err := UserRequest{Login: " ", Password: "secret"}.Validate()
+	assert.Equal(t, validator.Errors{{Field: "login", Message: msgRequired}}, err)
+
+	assert.NoError(t, UserRequest{Login: "test", Password: "secret"}.Validate())
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/client"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+const (
+	accrualJobLease      = time.Minute
+	accrualBackoffBase   = time.Second * 3
+	accrualBackoffMax    = time.Minute * 30
+	defaultAccrualMaxAge = time.Hour * 24 * 7
+)
+
+var unfinishedOrderStatuses = []string{OrderNew, OrderRegistered, OrderProcessing}
+
+// recoverUnfinishedOrders pages through orders that never reached a final
+// status and queues an accrual check for each of them.
+func (s *service) recoverUnfinishedOrders(ctx context.Context, pageSize int) error {
+	var (
+		lastOrderID string
+		recovered   int
+	)
+
+	for {
+		orders, err := s.storage.GetOrdersByStatus(ctx, unfinishedOrderStatuses, lastOrderID, pageSize)
+		if err != nil {
+			return err
+		}
+
+		if len(orders) == 0 {
+			break
+		}
+
+		if err := s.storage.EnqueueAccrualJobs(ctx, orders); err != nil {
+			return err
+		}
+
+		recovered += len(orders)
+		lastOrderID = orders[len(orders)-1].ID
+
+		if len(orders) < pageSize {
+			break
+		}
+	}
+
+	s.log.Info().Int("orders", recovered).Msg("unfinished orders were queued for accrual update")
+
+	return nil
+}
+
+// startAccrualUpdater claims due jobs for as long as ctx is alive. It claims
+// no more jobs than the workers can pick up right away and claims again as
+// soon as a worker takes one, so the workers are only held back by the
+// accrual system rate limit. It waits interval only when no job was due. It
+// closes the jobs channel on exit so the workers drain it and stop.
+func (s *service) startAccrualUpdater(ctx context.Context, interval time.Duration) {
+	defer close(s.accrualJobs)
+
+	var reportedAt time.Time
+
+	for ctx.Err() == nil {
+		if time.Since(reportedAt) >= interval {
+			s.reportAccrualQueue(ctx)
+			reportedAt = time.Now()
+		}
+
+		if s.claimAccrualJobs(ctx) > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// claimAccrualJobs claims as many due jobs as there are free slots in the
+// jobs channel, or one if there are none, and hands them to the workers,
+// waiting for a worker to take them. It returns the number of jobs handed
+// over. Jobs left over on shutdown keep their lease until it runs out.
+func (s *service) claimAccrualJobs(ctx context.Context) int {
+	free := cap(s.accrualJobs) - len(s.accrualJobs)
+	if free == 0 {
+		free = 1
+	}
+
+	jobs, err := s.storage.ClaimAccrualJobs(ctx, free, accrualJobLease)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to claim accrual jobs")
+		return 0
+	}
+
+	for i, job := range jobs {
+		select {
+		case <-ctx.Done():
+			return i
+		case s.accrualJobs <- job:
+		}
+	}
+
+	return len(jobs)
+}
+
+// startAccrualWorkers runs the workers until the jobs channel is closed. Once
+// ctx is done, the jobs left in the channel are dropped without a request, so
+// a shutdown doesn't wait out a rate limit pause; their leases run out and
+// they are claimed again later.
+func (s *service) startAccrualWorkers(ctx context.Context, workers int) {
+	accrualWorkers.Set(int64(workers))
+
+	for i := 0; i < workers; i++ {
+		s.workersWG.Add(1)
+
+		go func() {
+			defer s.workersWG.Done()
+
+			for job := range s.accrualJobs {
+				accrualBusyWorkers.Add(1)
+				s.processAccrualJob(ctx, job)
+				accrualBusyWorkers.Add(-1)
+			}
+		}()
+	}
+}
+
+func (s *service) reportAccrualQueue(ctx context.Context) {
+	accrualBufferedJobs.Set(int64(len(s.accrualJobs)))
+
+	depth, err := s.storage.CountPendingAccrualJobs(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to count pending accrual jobs")
+		return
+	}
+
+	accrualQueueDepth.Set(int64(depth))
+}
+
+func (s *service) processAccrualJob(ctx context.Context, job entity.AccrualJob) {
+	resp, err := s.accrualClient.GetAccrual(ctx, job.OrderID)
+	if ctx.Err() != nil {
+		s.log.Info().Str("order_id", job.OrderID).Msg("accrual check was cancelled by shutdown")
+		return
+	}
+
+	var rateLimitErr *client.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		s.log.Warn().Str("order_id", job.OrderID).Dur("retry_after", rateLimitErr.RetryAfter).Msg("accrual system rate limit exceeded")
+		s.rescheduleAccrualJob(ctx, job, rateLimitErr.RetryAfter)
+		return
+	}
+
+	if errors.Is(err, client.ErrOrderNotRegistered) {
+		s.log.Info().Str("order_id", job.OrderID).Int("attempt", job.Attempts).Msg(err.Error())
+		s.rescheduleAccrualJob(ctx, job, accrualBackoff(job.Attempts))
+		return
+	}
+
+	if err != nil {
+		s.log.Error().Err(err).Str("order_id", job.OrderID).Int("attempt", job.Attempts).Msg("failed to get accrual information")
+		s.rescheduleAccrualJob(ctx, job, accrualBackoff(job.Attempts))
+		return
+	}
+
+	order := entity.Order{
+		ID:      job.OrderID,
+		UserID:  job.UserID,
+		Accrual: int(resp.Accrual),
+		Status:  resp.Status,
+	}
+
+	if order.Status == OrderProcessed {
+		credit, err := s.orderCredit(ctx, order)
+		if err != nil {
+			s.log.Error().Err(err).Str("order_id", order.ID).Msg("failed to work out order bonuses")
+			s.rescheduleAccrualJob(ctx, job, accrualBackoff(job.Attempts))
+			return
+		}
+
+		if err := s.storage.CreditOrderAccrual(ctx, order, credit); err != nil {
+			s.log.Error().Err(err).Str("order_id", order.ID).Msg("failed to credit order accrual")
+			s.rescheduleAccrualJob(ctx, job, accrualBackoff(job.Attempts))
+			return
+		}
+
+		s.log.Info().Str("order_id", order.ID).Int("accrual", order.Accrual).Int("campaigns", len(credit.CampaignBonuses)).Msg("order accrual was credited")
+		return
+	}
+
+	if err := s.storage.UpdateOrder(order); err != nil {
+		s.log.Error().Err(err).Str("order_id", order.ID).Msg("failed to update order")
+		s.rescheduleAccrualJob(ctx, job, accrualBackoff(job.Attempts))
+		return
+	}
+
+	if order.Status != OrderInvalid {
+		s.rescheduleAccrualJob(ctx, job, accrualBackoff(job.Attempts))
+		return
+	}
+
+	if err := s.storage.CompleteAccrualJob(ctx, job.OrderID); err != nil {
+		s.log.Error().Err(err).Str("order_id", job.OrderID).Msg("failed to complete accrual job")
+	}
+}
+
+// orderCredit works out the campaign bonuses the order earns on top of its
+// accrual and passes on the tier and referral rules. The tier and referral
+// bonuses are up to the storage, as they depend on the user's lifetime
+// accrual and referral at the time of crediting.
+func (s *service) orderCredit(ctx context.Context, order entity.Order) (entity.OrderCredit, error) {
+	credit := entity.OrderCredit{Tiers: s.tiers}
+
+	var err error
+	credit.CampaignBonuses, err = s.campaignBonuses(ctx, order)
+	if err != nil {
+		return credit, err
+	}
+
+	credit.Referral = entity.ReferralCredit{
+		Bonus: int(s.referralBonus),
+		Limit: s.referralLimit,
+	}
+
+	return credit, nil
+}
+
+// rescheduleAccrualJob puts the job off by delay, or gives up on the order if
+// the next check would happen after the order has outlived accrualMaxAge.
+func (s *service) rescheduleAccrualJob(ctx context.Context, job entity.AccrualJob, delay time.Duration) {
+	if job.Age+delay > s.accrualMaxAge {
+		s.giveUpAccrualJob(ctx, job)
+		return
+	}
+
+	if err := s.storage.RescheduleAccrualJob(ctx, job.OrderID, delay); err != nil {
+		s.log.Error().Err(err).Str("order_id", job.OrderID).Msg("failed to reschedule accrual job")
+	}
+}
+
+func (s *service) giveUpAccrualJob(ctx context.Context, job entity.AccrualJob) {
+	order := entity.Order{
+		ID:     job.OrderID,
+		UserID: job.UserID,
+		Status: OrderUnresolved,
+	}
+
+	if err := s.storage.UpdateOrder(order); err != nil {
+		s.log.Error().Err(err).Str("order_id", job.OrderID).Msg("failed to mark order as unresolved")
+		return
+	}
+
+	if err := s.storage.CompleteAccrualJob(ctx, job.OrderID); err != nil {
+		s.log.Error().Err(err).Str("order_id", job.OrderID).Msg("failed to complete accrual job")
+		return
+	}
+
+	s.log.Warn().Str("order_id", job.OrderID).Int("attempts", job.Attempts).Dur("age", job.Age).Msg("gave up on accrual for order")
+}
+
+// accrualBackoff doubles the delay with every attempt up to
+// accrualBackoffMax and picks a random point in its upper half, so orders
+// uploaded together do not keep hitting the accrual system together.
+func accrualBackoff(attempt int) time.Duration {
+	delay := accrualBackoffMax
+	if attempt < 1 {
+		attempt = 1
+	}
+	if shift := attempt - 1; shift < 32 && accrualBackoffBase<<shift < accrualBackoffMax {
+		delay = accrualBackoffBase << shift
+	}
+
+	half := delay / 2
+
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
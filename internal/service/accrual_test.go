@@ -0,0 +1,458 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/client"
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func Test_service_processAccrualJob(t *testing.T) {
+	job := entity.AccrualJob{
+		OrderID:  "12345678903",
+		UserID:   1,
+		Attempts: 1,
+	}
+
+	tests := []struct {
+		name         string
+		job          entity.AccrualJob
+		statusCode   int
+		responseBody string
+		prepare      func(s *mocks.MockRepository)
+	}{
+		{
+			name:         "should credit order accrual when order is processed",
+			job:          job,
+			statusCode:   http.StatusOK,
+			responseBody: `{"order": "12345678903", "status": "PROCESSED", "accrual": 500}`,
+			prepare: func(s *mocks.MockRepository) {
+				gomock.InOrder(
+					s.EXPECT().
+						GetActiveCampaigns(gomock.Any()).
+						Return(nil, nil),
+					s.EXPECT().
+						CreditOrderAccrual(gomock.Any(), entity.Order{
+							ID:      "12345678903",
+							UserID:  1,
+							Accrual: 50000,
+							Status:  OrderProcessed,
+						}, entity.OrderCredit{}).
+						Return(nil),
+				)
+			},
+		},
+		{
+			name:         "should complete job when order is invalid",
+			job:          job,
+			statusCode:   http.StatusOK,
+			responseBody: `{"order": "12345678903", "status": "INVALID"}`,
+			prepare: func(s *mocks.MockRepository) {
+				gomock.InOrder(
+					s.EXPECT().
+						UpdateOrder(entity.Order{
+							ID:     "12345678903",
+							UserID: 1,
+							Status: OrderInvalid,
+						}).
+						Return(nil),
+					s.EXPECT().
+						CompleteAccrualJob(gomock.Any(), "12345678903").
+						Return(nil),
+				)
+			},
+		},
+		{
+			name:         "should reschedule job when order is still processing",
+			job:          job,
+			statusCode:   http.StatusOK,
+			responseBody: `{"order": "12345678903", "status": "PROCESSING"}`,
+			prepare: func(s *mocks.MockRepository) {
+				gomock.InOrder(
+					s.EXPECT().
+						UpdateOrder(entity.Order{
+							ID:     "12345678903",
+							UserID: 1,
+							Status: OrderProcessing,
+						}).
+						Return(nil),
+					s.EXPECT().
+						RescheduleAccrualJob(gomock.Any(), "12345678903", gomock.Any()).
+						Return(nil),
+				)
+			},
+		},
+		{
+			name:       "should reschedule job when accrual system fails",
+			job:        job,
+			statusCode: http.StatusInternalServerError,
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					RescheduleAccrualJob(gomock.Any(), "12345678903", gomock.Any()).
+					Return(nil)
+			},
+		},
+		{
+			name:       "should reschedule job after retry-after when rate limited",
+			job:        job,
+			statusCode: http.StatusTooManyRequests,
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					RescheduleAccrualJob(gomock.Any(), "12345678903", time.Second*60).
+					Return(nil)
+			},
+		},
+		{
+			name:       "should reschedule job when order is not registered yet",
+			job:        job,
+			statusCode: http.StatusNoContent,
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					RescheduleAccrualJob(gomock.Any(), "12345678903", gomock.Any()).
+					Return(nil)
+			},
+		},
+		{
+			name: "should mark order unresolved when it outlived max age",
+			job: entity.AccrualJob{
+				OrderID:  "12345678903",
+				UserID:   1,
+				Attempts: 40,
+				Age:      defaultAccrualMaxAge,
+			},
+			statusCode: http.StatusNoContent,
+			prepare: func(s *mocks.MockRepository) {
+				gomock.InOrder(
+					s.EXPECT().
+						UpdateOrder(entity.Order{
+							ID:     "12345678903",
+							UserID: 1,
+							Status: OrderUnresolved,
+						}).
+						Return(nil),
+					s.EXPECT().
+						CompleteAccrualJob(gomock.Any(), "12345678903").
+						Return(nil),
+				)
+			},
+		},
+		{
+			name:         "should reschedule job when crediting fails",
+			job:          job,
+			statusCode:   http.StatusOK,
+			responseBody: `{"order": "12345678903", "status": "PROCESSED", "accrual": 500}`,
+			prepare: func(s *mocks.MockRepository) {
+				gomock.InOrder(
+					s.EXPECT().
+						GetActiveCampaigns(gomock.Any()).
+						Return(nil, nil),
+					s.EXPECT().
+						CreditOrderAccrual(gomock.Any(), gomock.Any(), gomock.Any()).
+						Return(errInternal),
+					s.EXPECT().
+						RescheduleAccrualJob(gomock.Any(), "12345678903", gomock.Any()).
+						Return(nil),
+				)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.statusCode == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "60")
+				}
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.responseBody))
+			}))
+			defer server.Close()
+
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			tt.prepare(storage)
+
+			service := service{
+				log:           logger.NewLogger(),
+				storage:       storage,
+				accrualClient: client.NewAccrualClient(server.URL, 0),
+				accrualMaxAge: defaultAccrualMaxAge,
+			}
+
+			service.processAccrualJob(context.Background(), tt.job)
+		})
+	}
+}
+
+func Test_service_recoverUnfinishedOrders(t *testing.T) {
+	firstPage := []entity.Order{
+		{ID: "12345678903", UserID: 1, Status: OrderNew},
+		{ID: "2377225624", UserID: 2, Status: OrderProcessing},
+	}
+	secondPage := []entity.Order{
+		{ID: "49927398716", UserID: 1, Status: OrderRegistered},
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *mocks.MockRepository)
+		wantErr error
+	}{
+		{
+			name: "should enqueue every page of unfinished orders",
+			prepare: func(s *mocks.MockRepository) {
+				gomock.InOrder(
+					s.EXPECT().
+						GetOrdersByStatus(gomock.Any(), unfinishedOrderStatuses, "", 2).
+						Return(firstPage, nil),
+					s.EXPECT().
+						EnqueueAccrualJobs(gomock.Any(), firstPage).
+						Return(nil),
+					s.EXPECT().
+						GetOrdersByStatus(gomock.Any(), unfinishedOrderStatuses, "2377225624", 2).
+						Return(secondPage, nil),
+					s.EXPECT().
+						EnqueueAccrualJobs(gomock.Any(), secondPage).
+						Return(nil),
+				)
+			},
+		},
+		{
+			name: "should do nothing if there are no unfinished orders",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetOrdersByStatus(gomock.Any(), unfinishedOrderStatuses, "", 2).
+					Return(nil, nil)
+			},
+		},
+		{
+			name: "should return error if can't enqueue orders",
+			prepare: func(s *mocks.MockRepository) {
+				gomock.InOrder(
+					s.EXPECT().
+						GetOrdersByStatus(gomock.Any(), unfinishedOrderStatuses, "", 2).
+						Return(firstPage, nil),
+					s.EXPECT().
+						EnqueueAccrualJobs(gomock.Any(), firstPage).
+						Return(errInternal),
+				)
+			},
+			wantErr: errInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			tt.prepare(storage)
+
+			service := service{
+				log:     logger.NewLogger(),
+				storage: storage,
+			}
+
+			err := service.recoverUnfinishedOrders(context.Background(), 2)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func Test_service_accrualUpdater(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"order": "12345678903", "status": "INVALID"}`))
+	}))
+	defer server.Close()
+
+	jobs := []entity.AccrualJob{
+		{OrderID: "12345678903", UserID: 1},
+		{OrderID: "2377225624", UserID: 1},
+		{OrderID: "49927398716", UserID: 2},
+	}
+
+	ctrl := gomock.NewController(t)
+	storage := mocks.NewMockRepository(ctrl)
+
+	completed := make(chan string, len(jobs))
+
+	storage.EXPECT().
+		GetOrdersByStatus(gomock.Any(), unfinishedOrderStatuses, "", gomock.Any()).
+		Return(nil, nil)
+	storage.EXPECT().
+		CountPendingAccrualJobs(gomock.Any()).
+		Return(0, nil).
+		AnyTimes()
+	gomock.InOrder(
+		storage.EXPECT().
+			ClaimAccrualJobs(gomock.Any(), 2, accrualJobLease).
+			Return(jobs[:2], nil),
+		storage.EXPECT().
+			ClaimAccrualJobs(gomock.Any(), gomock.Any(), accrualJobLease).
+			Return(jobs[2:], nil),
+		storage.EXPECT().
+			ClaimAccrualJobs(gomock.Any(), gomock.Any(), accrualJobLease).
+			Return(nil, nil).
+			AnyTimes(),
+	)
+	storage.EXPECT().
+		UpdateOrder(gomock.Any()).
+		Return(nil).
+		Times(len(jobs))
+	storage.EXPECT().
+		CompleteAccrualJob(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, orderID string) error {
+			completed <- orderID
+			return nil
+		}).
+		Times(len(jobs))
+
+	s := &service{
+		log:            logger.NewLogger(),
+		storage:        storage,
+		accrualClient:  client.NewAccrualClient(server.URL, 0),
+		accrualMaxAge:  defaultAccrualMaxAge,
+		accrualJobs:    make(chan entity.AccrualJob, 2),
+		updaterStopped: make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopUpdater = cancel
+
+	s.startAccrualWorkers(ctx, 2)
+	go func() {
+		defer close(s.updaterStopped)
+		s.recoverUnfinishedOrders(ctx, 10)
+		// Jobs keep being claimed while they are due, without waiting out
+		// the interval.
+		s.startAccrualUpdater(ctx, time.Hour)
+	}()
+
+	for range jobs {
+		select {
+		case <-completed:
+		case <-time.After(time.Second * 5):
+			t.Fatal("accrual jobs were not processed in time")
+		}
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer shutdownCancel()
+
+	assert.NoError(t, s.Shutdown(shutdownCtx))
+	assert.Equal(t, int64(0), accrualBusyWorkers.Value())
+}
+
+func Test_service_Shutdown_rateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	storage := mocks.NewMockRepository(ctrl)
+
+	rescheduled := make(chan struct{}, 1)
+
+	storage.EXPECT().
+		CountPendingAccrualJobs(gomock.Any()).
+		Return(0, nil).
+		AnyTimes()
+	gomock.InOrder(
+		storage.EXPECT().
+			ClaimAccrualJobs(gomock.Any(), 2, accrualJobLease).
+			Return([]entity.AccrualJob{
+				{OrderID: "12345678903", UserID: 1},
+				{OrderID: "2377225624", UserID: 1},
+			}, nil),
+		storage.EXPECT().
+			ClaimAccrualJobs(gomock.Any(), gomock.Any(), accrualJobLease).
+			Return(nil, nil).
+			AnyTimes(),
+	)
+	storage.EXPECT().
+		RescheduleAccrualJob(gomock.Any(), "12345678903", time.Minute).
+		DoAndReturn(func(context.Context, string, time.Duration) error {
+			rescheduled <- struct{}{}
+			return nil
+		})
+
+	s := &service{
+		log:            logger.NewLogger(),
+		storage:        storage,
+		accrualClient:  client.NewAccrualClient(server.URL, 0),
+		accrualMaxAge:  defaultAccrualMaxAge,
+		accrualJobs:    make(chan entity.AccrualJob, 2),
+		updaterStopped: make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopUpdater = cancel
+
+	s.startAccrualWorkers(ctx, 1)
+	go func() {
+		defer close(s.updaterStopped)
+		s.startAccrualUpdater(ctx, time.Millisecond*10)
+	}()
+
+	select {
+	case <-rescheduled:
+	case <-time.After(time.Second * 5):
+		t.Fatal("rate limited job was not rescheduled in time")
+	}
+
+	// The second job is now waiting out the minute long pause.
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer shutdownCancel()
+
+	assert.NoError(t, s.Shutdown(shutdownCtx))
+}
+
+func Test_accrualBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{
+			name:    "should wait about base delay on first attempt",
+			attempt: 1,
+			min:     accrualBackoffBase / 2,
+			max:     accrualBackoffBase,
+		},
+		{
+			name:    "should double delay with every attempt",
+			attempt: 4,
+			min:     accrualBackoffBase * 4,
+			max:     accrualBackoffBase * 8,
+		},
+		{
+			name:    "should cap delay",
+			attempt: 100,
+			min:     accrualBackoffMax / 2,
+			max:     accrualBackoffMax,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				delay := accrualBackoff(tt.attempt)
+				assert.GreaterOrEqual(t, delay, tt.min)
+				assert.LessOrEqual(t, delay, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func (s *service) CreateCampaign(ctx context.Context, req models.CampaignRequest) (*models.CampaignResponse, error) {
+	multiplier, err := req.ParseMultiplier()
+	if err != nil {
+		return nil, err
+	}
+
+	// The period columns have no time zone, which the driver drops, so the
+	// period is stored in UTC.
+	campaign, err := s.storage.SaveCampaign(ctx, entity.Campaign{
+		Name:           req.Name,
+		Multiplier:     multiplier,
+		Bonus:          int(req.Bonus),
+		MinAccrual:     int(req.MinAccrual),
+		FirstOrderOnly: req.FirstOrderOnly,
+		StartsAt:       req.StartsAt.UTC(),
+		EndsAt:         req.EndsAt.UTC(),
+	})
+	if err != nil {
+		s.log.Error().Err(err).Str("campaign", req.Name).Msg("failed to save campaign")
+		return nil, err
+	}
+
+	resp := campaignResponse(*campaign)
+
+	return &resp, nil
+}
+
+func (s *service) GetCampaigns(ctx context.Context) ([]models.CampaignResponse, error) {
+	campaigns, err := s.storage.GetCampaigns(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to get campaigns")
+		return nil, err
+	}
+
+	resp := make([]models.CampaignResponse, 0, len(campaigns))
+	for _, c := range campaigns {
+		resp = append(resp, campaignResponse(c))
+	}
+
+	return resp, nil
+}
+
+// campaignBonuses works out the bonuses the order earns in the campaigns
+// running now. Like tier bonuses, they are rounded down to whole hundredths
+// of a point.
+func (s *service) campaignBonuses(ctx context.Context, order entity.Order) ([]entity.CampaignBonus, error) {
+	campaigns, err := s.storage.GetActiveCampaigns(ctx)
+	if err != nil || len(campaigns) == 0 {
+		return nil, err
+	}
+
+	firstOrder := -1
+
+	var bonuses []entity.CampaignBonus
+	for _, c := range campaigns {
+		if order.Accrual < c.MinAccrual {
+			continue
+		}
+
+		if c.FirstOrderOnly {
+			if firstOrder < 0 {
+				credited, err := s.storage.CountCreditedOrders(ctx, order.UserID)
+				if err != nil {
+					return nil, err
+				}
+
+				firstOrder = credited
+			}
+
+			if firstOrder > 0 {
+				continue
+			}
+		}
+
+		bonus := int(decimal.NewFromInt(int64(order.Accrual)).
+			Mul(c.Multiplier.Sub(decimal.NewFromInt(1))).
+			IntPart()) + c.Bonus
+
+		if bonus > 0 {
+			bonuses = append(bonuses, entity.CampaignBonus{
+				CampaignID:     c.ID,
+				Bonus:          bonus,
+				FirstOrderOnly: c.FirstOrderOnly,
+			})
+		}
+	}
+
+	return bonuses, nil
+}
+
+func campaignResponse(c entity.Campaign) models.CampaignResponse {
+	return models.CampaignResponse{
+		ID:             c.ID,
+		Name:           c.Name,
+		Multiplier:     json.Number(c.Multiplier.String()),
+		Bonus:          models.Amount(c.Bonus),
+		MinAccrual:     models.Amount(c.MinAccrual),
+		FirstOrderOnly: c.FirstOrderOnly,
+		StartsAt:       c.StartsAt.Format(time.RFC3339),
+		EndsAt:         c.EndsAt.Format(time.RFC3339),
+		CreatedAt:      c.CreatedAt.Format(time.RFC3339),
+	}
+}
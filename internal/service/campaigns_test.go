@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func Test_service_campaignBonuses(t *testing.T) {
+	order := entity.Order{ID: "12345678903", UserID: 1, Accrual: 50005, Status: OrderProcessed}
+
+	doublePoints := entity.Campaign{ID: 1, Multiplier: decimal.NewFromInt(2)}
+	firstOrder := entity.Campaign{ID: 2, Multiplier: decimal.NewFromInt(1), Bonus: 10000, FirstOrderOnly: true}
+	bigOrders := entity.Campaign{ID: 3, Multiplier: decimal.RequireFromString("1.5"), MinAccrual: 100000}
+
+	tests := []struct {
+		name    string
+		prepare func(s *mocks.MockRepository)
+		want    []entity.CampaignBonus
+		wantErr error
+	}{
+		{
+			name: "should give no bonus without active campaigns",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().GetActiveCampaigns(gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name: "should give bonuses of every campaign the order qualifies for",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetActiveCampaigns(gomock.Any()).
+					Return([]entity.Campaign{doublePoints, firstOrder, bigOrders}, nil)
+				s.EXPECT().CountCreditedOrders(gomock.Any(), 1).Return(0, nil)
+			},
+			want: []entity.CampaignBonus{
+				{CampaignID: 1, Bonus: 50005},
+				{CampaignID: 2, Bonus: 10000, FirstOrderOnly: true},
+			},
+		},
+		{
+			name: "should skip first order campaign for returning user",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetActiveCampaigns(gomock.Any()).
+					Return([]entity.Campaign{firstOrder}, nil)
+				s.EXPECT().CountCreditedOrders(gomock.Any(), 1).Return(3, nil)
+			},
+		},
+		{
+			name: "should return error when campaigns can't be loaded",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().GetActiveCampaigns(gomock.Any()).Return(nil, errInternal)
+			},
+			wantErr: errInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			tt.prepare(storage)
+
+			service := service{
+				log:     logger.NewLogger(),
+				storage: storage,
+			}
+
+			bonuses, err := service.campaignBonuses(context.Background(), order)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, bonuses)
+		})
+	}
+}
+
+func Test_service_CreateCampaign_utc(t *testing.T) {
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	startsAt := time.Date(2023, 9, 16, 0, 0, 0, 0, zone)
+	endsAt := time.Date(2023, 9, 17, 0, 0, 0, 0, zone)
+
+	ctrl := gomock.NewController(t)
+	storage := mocks.NewMockRepository(ctrl)
+
+	storage.EXPECT().
+		SaveCampaign(gomock.Any(), entity.Campaign{
+			Name:       "weekend",
+			Multiplier: decimal.NewFromInt(1),
+			StartsAt:   time.Date(2023, 9, 15, 22, 0, 0, 0, time.UTC),
+			EndsAt:     time.Date(2023, 9, 16, 22, 0, 0, 0, time.UTC),
+		}).
+		DoAndReturn(func(_ context.Context, c entity.Campaign) (*entity.Campaign, error) {
+			return &c, nil
+		})
+
+	s := &service{
+		log:     logger.NewLogger(),
+		storage: storage,
+	}
+
+	_, err := s.CreateCampaign(context.Background(), models.CampaignRequest{
+		Name:     "weekend",
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+	})
+	assert.NoError(t, err)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"github.com/pkg/errors"
-	"github.com/shopspring/decimal"
 
 	"github.com/PrahaTurbo/gophermart/internal/auth"
 )
@@ -15,38 +14,52 @@ const (
 	OrderInvalid    = "INVALID"
 	OrderProcessing = "PROCESSING"
 	OrderProcessed  = "PROCESSED"
+	OrderUnresolved = "UNRESOLVED"
+)
+
+// Outcomes of an order number of a batch upload. They match the responses to
+// a single order upload: 202, 200, 409 and 422.
+const (
+	UploadAccepted       = "ACCEPTED"
+	UploadByCurrentUser  = "UPLOADED_BY_YOU"
+	UploadByAnotherUser  = "UPLOADED_BY_ANOTHER_USER"
+	UploadInvalidOrderID = "INVALID"
+)
+
+const (
+	WithdrawalProcessed = "PROCESSED"
+	WithdrawalReversed  = "REVERSED"
 )
 
 var (
 	ErrInvalidOrderID     = errors.New("order id didn't pass luhn algorithm validation")
 	ErrOrderByAnotherUser = errors.New("order was uploaded by another user")
 	ErrOrderByCurrentUser = errors.New("order was uploaded by current user")
+	ErrTooManyOrders      = errors.New("too many orders in one batch")
+	ErrOrderNotFound      = errors.New("order not found")
 
-	ErrBalanceNotEnough = errors.New("not enough funds on the balance")
+	ErrBalanceNotEnough    = errors.New("not enough funds on the balance")
+	ErrWithdrawalOrderUsed = errors.New("order number was already used for a withdrawal")
+	ErrInvalidWithdrawSum  = errors.New("withdraw sum must be greater than zero")
+	ErrWithdrawalNotFound  = errors.New("withdrawal not found")
 
-	ErrExtractFromContext = errors.New("cannot extract userID from context")
-)
+	ErrRecipientNotFound     = errors.New("recipient not found")
+	ErrTransferToSelf        = errors.New("points can't be transferred to yourself")
+	ErrTransferLimitExceeded = errors.New("transfer exceeds the transfer limit")
 
-func validateLuhn(s string) bool {
-	n := len(s)
-	sum := 0
-	double := false
-	for i := n - 1; i >= 0; i-- {
-		c := int(s[i] - '0')
-
-		if double {
-			c = c * 2
-			if c > 9 {
-				c = c - 9
-			}
-		}
-		double = !double
-
-		sum += c
-	}
+	ErrReferralCodeNotFound = errors.New("referral code not found")
 
-	return sum%10 == 0
-}
+	ErrRewardNotFound   = errors.New("reward not found")
+	ErrRewardOutOfStock = errors.New("reward is out of stock")
+
+	ErrHoldNotFound  = errors.New("hold not found")
+	ErrHoldNotActive = errors.New("hold was already captured, released or has expired")
+
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for another request")
+	ErrIdempotentRequestRunning = errors.New("request with this idempotency key is still in progress")
+
+	ErrExtractFromContext = errors.New("cannot extract userID from context")
+)
 
 func extractUserIDFromCtx(ctx context.Context) (int, error) {
 	userIDVal := ctx.Value(auth.UserIDKey)
@@ -57,25 +70,3 @@ func extractUserIDFromCtx(ctx context.Context) (int, error) {
 
 	return userID, nil
 }
-
-func amountToFloat64(amount int) float64 {
-	const amountDivider = 100
-
-	d := decimal.NewFromInt(int64(amount)).Div(decimal.NewFromInt(amountDivider)).Round(2)
-	result, ok := d.Float64()
-
-	if !ok {
-		return float64(amount) / amountDivider
-	}
-
-	return result
-}
-
-func amountToInt(amount float64) int {
-	const amountMultiplier = 100
-
-	d := decimal.NewFromFloat(amount).Mul(decimal.NewFromInt(amountMultiplier))
-	n := d.IntPart()
-
-	return int(n)
-}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+	"github.com/PrahaTurbo/gophermart/internal/validator"
+)
+
+const (
+	defaultHoldTTL     = time.Minute * 15
+	holdExpiryInterval = time.Second * 30
+	holdExpiryPageSize = 100
+)
+
+// HoldPoints reserves points for an order until the order is confirmed with
+// CaptureHold or cancelled with ReleaseHold. Holds that are neither expire
+// after the configured TTL.
+func (s *service) HoldPoints(ctx context.Context, req models.HoldRequest) (*models.HoldResponse, error) {
+	userID, err := extractUserIDFromCtx(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to extracrt user from context")
+		return nil, err
+	}
+
+	if !validator.Luhn(req.Order) {
+		s.log.Error().Str("order", req.Order).Msg(ErrInvalidOrderID.Error())
+		return nil, ErrInvalidOrderID
+	}
+
+	if req.Sum <= 0 {
+		s.log.Error().Str("sum", req.Sum.String()).Int("user", userID).Msg(ErrInvalidWithdrawSum.Error())
+		return nil, ErrInvalidWithdrawSum
+	}
+
+	hold := entity.Hold{
+		UserID:  userID,
+		OrderID: req.Order,
+		Sum:     int(req.Sum),
+	}
+
+	h, err := s.storage.HoldPoints(ctx, hold, s.holdTTL)
+	if errors.Is(err, storage.ErrInsufficientFunds) {
+		s.log.Info().Int("user", userID).Int("sum", hold.Sum).Msg(ErrBalanceNotEnough.Error())
+		return nil, ErrBalanceNotEnough
+	}
+
+	if errors.Is(err, storage.ErrWithdrawalOrderExists) {
+		s.log.Info().Int("user", userID).Str("order", hold.OrderID).Msg(ErrWithdrawalOrderUsed.Error())
+		return nil, ErrWithdrawalOrderUsed
+	}
+
+	if err != nil {
+		s.log.Error().Err(err).Int("user", userID).Msg("failed to hold points")
+		return nil, err
+	}
+
+	s.log.Info().Int("user", userID).Str("order", h.OrderID).Int("sum", h.Sum).Msg("points were held")
+
+	resp := holdResponse(*h)
+
+	return &resp, nil
+}
+
+func (s *service) CaptureHold(ctx context.Context, orderID string) (*models.HoldResponse, error) {
+	return s.resolveHold(ctx, orderID, "captured", s.storage.CaptureHold)
+}
+
+func (s *service) ReleaseHold(ctx context.Context, orderID string) (*models.HoldResponse, error) {
+	return s.resolveHold(ctx, orderID, "released", s.storage.ReleaseHold)
+}
+
+func (s *service) resolveHold(
+	ctx context.Context,
+	orderID string,
+	action string,
+	resolve func(ctx context.Context, userID int, orderID string) (*entity.Hold, error),
+) (*models.HoldResponse, error) {
+	userID, err := extractUserIDFromCtx(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to extracrt user from context")
+		return nil, err
+	}
+
+	h, err := resolve(ctx, userID, orderID)
+	if errors.Is(err, storage.ErrHoldNotFound) {
+		s.log.Info().Int("user", userID).Str("order", orderID).Msg(ErrHoldNotFound.Error())
+		return nil, ErrHoldNotFound
+	}
+
+	if errors.Is(err, storage.ErrHoldNotActive) {
+		s.log.Info().Int("user", userID).Str("order", orderID).Msg(ErrHoldNotActive.Error())
+		return nil, ErrHoldNotActive
+	}
+
+	if errors.Is(err, storage.ErrWithdrawalOrderExists) {
+		s.log.Info().Int("user", userID).Str("order", orderID).Msg(ErrWithdrawalOrderUsed.Error())
+		return nil, ErrWithdrawalOrderUsed
+	}
+
+	if err != nil {
+		s.log.Error().Err(err).Int("user", userID).Str("order", orderID).Msg("failed to resolve hold")
+		return nil, err
+	}
+
+	s.log.Info().Int("user", userID).Str("order", orderID).Str("status", h.Status).Msg("hold was " + action)
+
+	resp := holdResponse(*h)
+
+	return &resp, nil
+}
+
+// expireHolds releases the holds that outlived their TTL, a page at a time.
+func (s *service) expireHolds(ctx context.Context) error {
+	for {
+		expired, err := s.storage.ExpireHolds(ctx, holdExpiryPageSize)
+		if err != nil {
+			return err
+		}
+
+		if expired > 0 {
+			s.log.Info().Int("holds", expired).Msg("expired holds were released")
+		}
+
+		if expired < holdExpiryPageSize {
+			return nil
+		}
+	}
+}
+
+func holdResponse(h entity.Hold) models.HoldResponse {
+	resp := models.HoldResponse{
+		Order:     h.OrderID,
+		Sum:       models.Amount(h.Sum),
+		Status:    h.Status,
+		CreatedAt: h.CreatedAt.Format(time.RFC3339),
+		ExpiresAt: h.ExpiresAt.Format(time.RFC3339),
+	}
+
+	if !h.ResolvedAt.IsZero() {
+		resp.ResolvedAt = h.ResolvedAt.Format(time.RFC3339)
+	}
+
+	return resp
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/auth"
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func Test_service_HoldPoints(t *testing.T) {
+	now := time.Now()
+
+	service := service{
+		log:     logger.NewLogger(),
+		holdTTL: time.Minute,
+	}
+
+	type want struct {
+		hold *models.HoldResponse
+		err  error
+	}
+
+	tests := []struct {
+		name    string
+		req     models.HoldRequest
+		prepare func(s *mocks.MockRepository)
+		want    want
+	}{
+		{
+			name: "should hold points",
+			req: models.HoldRequest{
+				Order: "12345678903",
+				Sum:   1300,
+			},
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					HoldPoints(gomock.Any(), entity.Hold{
+						UserID:  1,
+						OrderID: "12345678903",
+						Sum:     1300,
+					}, time.Minute).
+					Return(&entity.Hold{
+						ID:        1,
+						UserID:    1,
+						OrderID:   "12345678903",
+						Sum:       1300,
+						Status:    entity.HoldHeld,
+						CreatedAt: now,
+						ExpiresAt: now.Add(time.Minute),
+					}, nil)
+			},
+			want: want{
+				hold: &models.HoldResponse{
+					Order:     "12345678903",
+					Sum:       1300,
+					Status:    entity.HoldHeld,
+					CreatedAt: now.Format(time.RFC3339),
+					ExpiresAt: now.Add(time.Minute).Format(time.RFC3339),
+				},
+			},
+		},
+		{
+			name: "should return error if order id doesn't match luhn algorithm",
+			req: models.HoldRequest{
+				Order: "123",
+				Sum:   1300,
+			},
+			prepare: func(s *mocks.MockRepository) {},
+			want: want{
+				err: ErrInvalidOrderID,
+			},
+		},
+		{
+			name: "should return error if sum is negative",
+			req: models.HoldRequest{
+				Order: "12345678903",
+				Sum:   -1300,
+			},
+			prepare: func(s *mocks.MockRepository) {},
+			want: want{
+				err: ErrInvalidWithdrawSum,
+			},
+		},
+		{
+			name: "should return error if balance lower than hold sum",
+			req: models.HoldRequest{
+				Order: "12345678903",
+				Sum:   1300,
+			},
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					HoldPoints(gomock.Any(), gomock.Any(), time.Minute).
+					Return(nil, storage.ErrInsufficientFunds)
+			},
+			want: want{
+				err: ErrBalanceNotEnough,
+			},
+		},
+		{
+			name: "should return error if order number was already used",
+			req: models.HoldRequest{
+				Order: "12345678903",
+				Sum:   1300,
+			},
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					HoldPoints(gomock.Any(), gomock.Any(), time.Minute).
+					Return(nil, storage.ErrWithdrawalOrderExists)
+			},
+			want: want{
+				err: ErrWithdrawalOrderUsed,
+			},
+		},
+		{
+			name: "should return error if can't hold points",
+			req: models.HoldRequest{
+				Order: "12345678903",
+				Sum:   1300,
+			},
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					HoldPoints(gomock.Any(), gomock.Any(), time.Minute).
+					Return(nil, errInternal)
+			},
+			want: want{
+				err: errInternal,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			tt.prepare(storage)
+			service.storage = storage
+
+			ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+			result, err := service.HoldPoints(ctx, tt.req)
+
+			assert.Equal(t, tt.want.err, err)
+			assert.Equal(t, tt.want.hold, result)
+		})
+	}
+}
+
+func Test_service_CaptureHold(t *testing.T) {
+	now := time.Now()
+
+	service := service{
+		log: logger.NewLogger(),
+	}
+
+	type want struct {
+		hold *models.HoldResponse
+		err  error
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *mocks.MockRepository)
+		want    want
+	}{
+		{
+			name: "should capture hold",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					CaptureHold(gomock.Any(), 1, "12345678903").
+					Return(&entity.Hold{
+						ID:         1,
+						UserID:     1,
+						OrderID:    "12345678903",
+						Sum:        1300,
+						Status:     entity.HoldCaptured,
+						CreatedAt:  now,
+						ExpiresAt:  now,
+						ResolvedAt: now,
+					}, nil)
+			},
+			want: want{
+				hold: &models.HoldResponse{
+					Order:      "12345678903",
+					Sum:        1300,
+					Status:     entity.HoldCaptured,
+					CreatedAt:  now.Format(time.RFC3339),
+					ExpiresAt:  now.Format(time.RFC3339),
+					ResolvedAt: now.Format(time.RFC3339),
+				},
+			},
+		},
+		{
+			name: "should return error if hold doesn't exist",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					CaptureHold(gomock.Any(), 1, "12345678903").
+					Return(nil, storage.ErrHoldNotFound)
+			},
+			want: want{
+				err: ErrHoldNotFound,
+			},
+		},
+		{
+			name: "should return error if hold was released",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					CaptureHold(gomock.Any(), 1, "12345678903").
+					Return(nil, storage.ErrHoldNotActive)
+			},
+			want: want{
+				err: ErrHoldNotActive,
+			},
+		},
+		{
+			name: "should return error if can't capture hold",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					CaptureHold(gomock.Any(), 1, "12345678903").
+					Return(nil, errInternal)
+			},
+			want: want{
+				err: errInternal,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			tt.prepare(storage)
+			service.storage = storage
+
+			ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+			result, err := service.CaptureHold(ctx, "12345678903")
+
+			assert.Equal(t, tt.want.err, err)
+			assert.Equal(t, tt.want.hold, result)
+		})
+	}
+}
+
+func Test_service_expireHolds(t *testing.T) {
+	service := service{
+		log: logger.NewLogger(),
+	}
+
+	ctrl := gomock.NewController(t)
+	storage := mocks.NewMockRepository(ctrl)
+	service.storage = storage
+
+	gomock.InOrder(
+		storage.EXPECT().ExpireHolds(gomock.Any(), holdExpiryPageSize).Return(holdExpiryPageSize, nil),
+		storage.EXPECT().ExpireHolds(gomock.Any(), holdExpiryPageSize).Return(3, nil),
+	)
+
+	err := service.expireHolds(context.Background())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+const (
+	idempotencyCleanupInterval = time.Hour
+	idempotencyCleanupPageSize = 1000
+)
+
+// BeginIdempotentRequest claims the idempotency key for the request. It
+// returns nil if the request should be handled now, or the stored response
+// of the first request made with the key.
+func (s *service) BeginIdempotentRequest(ctx context.Context, key string, request []byte) (*models.IdempotentResponse, error) {
+	userID, err := extractUserIDFromCtx(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to extracrt user from context")
+		return nil, err
+	}
+
+	hash := sha256.Sum256(request)
+	rec := entity.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: hex.EncodeToString(hash[:]),
+	}
+
+	existing, err := s.storage.BeginIdempotentRequest(ctx, rec)
+	if err != nil {
+		s.log.Error().Err(err).Int("user", userID).Str("key", key).Msg("failed to begin idempotent request")
+		return nil, err
+	}
+
+	if existing == nil {
+		return nil, nil
+	}
+
+	if existing.RequestHash != rec.RequestHash {
+		s.log.Info().Int("user", userID).Str("key", key).Msg(ErrIdempotencyKeyReused.Error())
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	if existing.StatusCode == 0 {
+		s.log.Info().Int("user", userID).Str("key", key).Msg(ErrIdempotentRequestRunning.Error())
+		return nil, ErrIdempotentRequestRunning
+	}
+
+	s.log.Info().Int("user", userID).Str("key", key).Msg("replaying stored response")
+
+	return &models.IdempotentResponse{
+		StatusCode:  existing.StatusCode,
+		ContentType: existing.ContentType,
+		Body:        existing.ResponseBody,
+	}, nil
+}
+
+// CompleteIdempotentRequest stores the response of the request holding the
+// key. The user is passed explicitly, so the response can be stored on a
+// context that outlives the request.
+func (s *service) CompleteIdempotentRequest(ctx context.Context, userID int, key string, resp models.IdempotentResponse) error {
+	rec := entity.IdempotencyRecord{
+		UserID:       userID,
+		Key:          key,
+		StatusCode:   resp.StatusCode,
+		ContentType:  resp.ContentType,
+		ResponseBody: resp.Body,
+	}
+
+	if err := s.storage.CompleteIdempotentRequest(ctx, rec); err != nil {
+		s.log.Error().Err(err).Int("user", userID).Str("key", key).Msg("failed to store idempotent response")
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey frees the key of a failed request, so it can be
+// retried right away.
+func (s *service) ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error {
+	if err := s.storage.ReleaseIdempotencyKey(ctx, userID, key); err != nil {
+		s.log.Error().Err(err).Int("user", userID).Str("key", key).Msg("failed to release idempotency key")
+		return err
+	}
+
+	return nil
+}
+
+// deleteExpiredIdempotencyKeys deletes the keys past their retention, a page
+// at a time.
+func (s *service) deleteExpiredIdempotencyKeys(ctx context.Context) error {
+	for {
+		deleted, err := s.storage.DeleteExpiredIdempotencyKeys(ctx, idempotencyCleanupPageSize)
+		if err != nil {
+			return err
+		}
+
+		if deleted > 0 {
+			s.log.Info().Int("keys", deleted).Msg("expired idempotency keys were deleted")
+		}
+
+		if deleted < idempotencyCleanupPageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/auth"
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func Test_service_BeginIdempotentRequest(t *testing.T) {
+	service := service{
+		log: logger.NewLogger(),
+	}
+
+	request := []byte(`POST /api/user/balance/withdraw
+{"order": "12345678903", "sum": 12}`)
+	hash := sha256.Sum256(request)
+	requestHash := hex.EncodeToString(hash[:])
+
+	type want struct {
+		resp *models.IdempotentResponse
+		err  error
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *mocks.MockRepository)
+		want    want
+	}{
+		{
+			name: "should claim a new key",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					BeginIdempotentRequest(gomock.Any(), entity.IdempotencyRecord{
+						UserID:      1,
+						Key:         "key",
+						RequestHash: requestHash,
+					}).
+					Return(nil, nil)
+			},
+		},
+		{
+			name: "should replay the stored response",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					BeginIdempotentRequest(gomock.Any(), gomock.Any()).
+					Return(&entity.IdempotencyRecord{
+						UserID:       1,
+						Key:          "key",
+						RequestHash:  requestHash,
+						StatusCode:   402,
+						ContentType:  "application/json",
+						ResponseBody: []byte(`{}`),
+					}, nil)
+			},
+			want: want{
+				resp: &models.IdempotentResponse{
+					StatusCode:  402,
+					ContentType: "application/json",
+					Body:        []byte(`{}`),
+				},
+			},
+		},
+		{
+			name: "should return error if key was used for another request",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					BeginIdempotentRequest(gomock.Any(), gomock.Any()).
+					Return(&entity.IdempotencyRecord{
+						UserID:      1,
+						Key:         "key",
+						RequestHash: "another",
+						StatusCode:  200,
+					}, nil)
+			},
+			want: want{
+				err: ErrIdempotencyKeyReused,
+			},
+		},
+		{
+			name: "should return error if first request is still running",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					BeginIdempotentRequest(gomock.Any(), gomock.Any()).
+					Return(&entity.IdempotencyRecord{
+						UserID:      1,
+						Key:         "key",
+						RequestHash: requestHash,
+					}, nil)
+			},
+			want: want{
+				err: ErrIdempotentRequestRunning,
+			},
+		},
+		{
+			name: "should return error if can't claim the key",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					BeginIdempotentRequest(gomock.Any(), gomock.Any()).
+					Return(nil, errInternal)
+			},
+			want: want{
+				err: errInternal,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			tt.prepare(storage)
+			service.storage = storage
+
+			ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+			resp, err := service.BeginIdempotentRequest(ctx, "key", request)
+			assert.Equal(t, tt.want.err, err)
+			assert.Equal(t, tt.want.resp, resp)
+		})
+	}
+}
+
+func Test_service_deleteExpiredIdempotencyKeys(t *testing.T) {
+	service := service{
+		log: logger.NewLogger(),
+	}
+
+	ctrl := gomock.NewController(t)
+	storage := mocks.NewMockRepository(ctrl)
+	service.storage = storage
+
+	gomock.InOrder(
+		storage.EXPECT().
+			DeleteExpiredIdempotencyKeys(gomock.Any(), idempotencyCleanupPageSize).
+			Return(idempotencyCleanupPageSize, nil),
+		storage.EXPECT().
+			DeleteExpiredIdempotencyKeys(gomock.Any(), idempotencyCleanupPageSize).
+			Return(7, nil),
+	)
+
+	err := service.deleteExpiredIdempotencyKeys(context.Background())
+	assert.NoError(t, err)
+}
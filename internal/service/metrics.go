@@ -0,0 +1,21 @@
+package service
+
+import "expvar"
+
+// Metrics holds the accrual updater gauges. They are kept out of the global
+// expvar registry, which also publishes the command line and memory stats.
+var Metrics = new(expvar.Map).Init()
+
+var (
+	accrualQueueDepth   = newMetric("accrual_queue_depth")
+	accrualBufferedJobs = newMetric("accrual_buffered_jobs")
+	accrualWorkers      = newMetric("accrual_workers")
+	accrualBusyWorkers  = newMetric("accrual_busy_workers")
+)
+
+func newMetric(name string) *expvar.Int {
+	v := new(expvar.Int)
+	Metrics.Set(name, v)
+
+	return v
+}
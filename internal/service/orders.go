@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/validator"
+)
+
+// MaxBatchOrders is the most order numbers a batch upload can take.
+const MaxBatchOrders = 1000
+
+// ProcessOrders uploads a batch of order numbers and reports the outcome of
+// each in the order they were given. Valid numbers are stored in one go;
+// a number given twice is accepted once and then reported as uploaded by the
+// user, like a repeated single upload.
+func (s *service) ProcessOrders(ctx context.Context, orderIDs []string) ([]models.OrderUploadResult, error) {
+	userID, err := extractUserIDFromCtx(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to extracrt user from context")
+		return nil, err
+	}
+
+	if len(orderIDs) > MaxBatchOrders {
+		s.log.Info().Int("user", userID).Int("orders", len(orderIDs)).Msg(ErrTooManyOrders.Error())
+		return nil, ErrTooManyOrders
+	}
+
+	valid := make([]string, 0, len(orderIDs))
+	for _, orderID := range orderIDs {
+		if validator.Luhn(orderID) {
+			valid = append(valid, orderID)
+		}
+	}
+
+	statuses := make(map[string]string, len(valid))
+	if len(valid) > 0 {
+		uploads, err := s.storage.SaveOrders(ctx, userID, valid, OrderNew)
+		if err != nil {
+			s.log.Error().Err(err).Int("user", userID).Int("orders", len(valid)).Msg("failed to save orders")
+			return nil, err
+		}
+
+		for _, upload := range uploads {
+			ownerID := upload.OwnerID
+			if ownerID == 0 {
+				ownerID, err = s.getOrderOwner(ctx, upload.OrderID)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			switch {
+			case upload.Created:
+				statuses[upload.OrderID] = UploadAccepted
+			case ownerID == userID:
+				statuses[upload.OrderID] = UploadByCurrentUser
+			default:
+				statuses[upload.OrderID] = UploadByAnotherUser
+			}
+		}
+	}
+
+	results := make([]models.OrderUploadResult, 0, len(orderIDs))
+	seen := make(map[string]bool, len(valid))
+	for _, orderID := range orderIDs {
+		status, ok := statuses[orderID]
+		if !ok {
+			status = UploadInvalidOrderID
+		}
+
+		if status == UploadAccepted && seen[orderID] {
+			status = UploadByCurrentUser
+		}
+		seen[orderID] = true
+
+		results = append(results, models.OrderUploadResult{Number: orderID, Status: status})
+	}
+
+	s.log.Info().Int("user", userID).Int("orders", len(orderIDs)).Msg("order batch was uploaded")
+
+	return results, nil
+}
+
+// GetUserOrder returns one of the user's orders. An order uploaded by another
+// user is reported as not found, so its existence is not given away.
+func (s *service) GetUserOrder(ctx context.Context, orderID string) (*models.OrderResponse, error) {
+	userID, err := extractUserIDFromCtx(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to extracrt user from context")
+		return nil, err
+	}
+
+	order, err := s.storage.GetOrder(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && order.UserID != userID {
+		return nil, ErrOrderNotFound
+	}
+
+	if err != nil {
+		s.log.Error().Err(err).Str("order", orderID).Msg("failed to get order for provided order id")
+		return nil, err
+	}
+
+	resp := orderResponse(*order)
+
+	return &resp, nil
+}
+
+// getOrderOwner looks up the owner of an order a concurrent upload created.
+func (s *service) getOrderOwner(ctx context.Context, orderID string) (int, error) {
+	order, err := s.storage.GetOrder(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = errors.Errorf("order %s was neither created nor found", orderID)
+	}
+
+	if err != nil {
+		s.log.Error().Err(err).Str("order", orderID).Msg("failed to get order for provided order id")
+		return 0, err
+	}
+
+	return order.UserID, nil
+}
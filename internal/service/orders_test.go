@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/auth"
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func Test_service_ProcessOrders(t *testing.T) {
+	type want struct {
+		results []models.OrderUploadResult
+		err     error
+	}
+
+	tests := []struct {
+		name     string
+		orderIDs []string
+		prepare  func(s *mocks.MockRepository)
+		want     want
+	}{
+		{
+			name:     "should report outcome of every order number",
+			orderIDs: []string{"12345678903", "123", "2377225624", "49927398716", "12345678903"},
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					SaveOrders(gomock.Any(), 1, []string{"12345678903", "2377225624", "49927398716", "12345678903"}, OrderNew).
+					Return([]entity.OrderUpload{
+						{OrderID: "12345678903", OwnerID: 1, Created: true},
+						{OrderID: "2377225624", OwnerID: 1},
+						{OrderID: "49927398716", OwnerID: 2},
+					}, nil)
+			},
+			want: want{
+				results: []models.OrderUploadResult{
+					{Number: "12345678903", Status: UploadAccepted},
+					{Number: "123", Status: UploadInvalidOrderID},
+					{Number: "2377225624", Status: UploadByCurrentUser},
+					{Number: "49927398716", Status: UploadByAnotherUser},
+					{Number: "12345678903", Status: UploadByCurrentUser},
+				},
+			},
+		},
+		{
+			name:     "should look up owner of order created concurrently",
+			orderIDs: []string{"12345678903"},
+			prepare: func(s *mocks.MockRepository) {
+				gomock.InOrder(
+					s.EXPECT().
+						SaveOrders(gomock.Any(), 1, []string{"12345678903"}, OrderNew).
+						Return([]entity.OrderUpload{{OrderID: "12345678903"}}, nil),
+					s.EXPECT().
+						GetOrder(gomock.Any(), "12345678903").
+						Return(&entity.Order{ID: "12345678903", UserID: 2}, nil),
+				)
+			},
+			want: want{
+				results: []models.OrderUploadResult{
+					{Number: "12345678903", Status: UploadByAnotherUser},
+				},
+			},
+		},
+		{
+			name:     "should not touch storage when every number is invalid",
+			orderIDs: []string{"123"},
+			prepare:  func(s *mocks.MockRepository) {},
+			want: want{
+				results: []models.OrderUploadResult{
+					{Number: "123", Status: UploadInvalidOrderID},
+				},
+			},
+		},
+		{
+			name:     "should reject batch that is too large",
+			orderIDs: make([]string, MaxBatchOrders+1),
+			prepare:  func(s *mocks.MockRepository) {},
+			want: want{
+				err: ErrTooManyOrders,
+			},
+		},
+		{
+			name:     "should return error when orders can't be saved",
+			orderIDs: []string{"12345678903"},
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					SaveOrders(gomock.Any(), 1, []string{"12345678903"}, OrderNew).
+					Return(nil, errInternal)
+			},
+			want: want{
+				err: errInternal,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			tt.prepare(storage)
+
+			service := service{
+				log:     logger.NewLogger(),
+				storage: storage,
+			}
+
+			ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+			results, err := service.ProcessOrders(ctx, tt.orderIDs)
+
+			assert.ErrorIs(t, err, tt.want.err)
+			assert.Equal(t, tt.want.results, results)
+		})
+	}
+}
+
+func Test_service_GetUserOrder(t *testing.T) {
+	uploadedAt := time.Date(2023, 9, 22, 10, 0, 0, 0, time.UTC)
+	creditedAt := uploadedAt.Add(time.Minute)
+
+	service := service{
+		log: logger.NewLogger(),
+	}
+
+	type want struct {
+		order *models.OrderResponse
+		err   error
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *mocks.MockRepository)
+		want    want
+	}{
+		{
+			name: "should return user's order with timestamps",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetOrder(gomock.Any(), "12345678903").
+					Return(&entity.Order{
+						ID:         "12345678903",
+						UserID:     1,
+						Accrual:    13400,
+						Status:     OrderProcessed,
+						UploadedAt: uploadedAt,
+						CreditedAt: creditedAt,
+					}, nil)
+			},
+			want: want{
+				order: &models.OrderResponse{
+					ID:         "12345678903",
+					Accrual:    13400,
+					Status:     OrderProcessed,
+					UploadedAt: uploadedAt.Format(time.RFC3339),
+					CreditedAt: creditedAt.Format(time.RFC3339),
+				},
+			},
+		},
+		{
+			name: "should return not found if order doesn't exist",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetOrder(gomock.Any(), "12345678903").
+					Return(nil, sql.ErrNoRows)
+			},
+			want: want{
+				err: ErrOrderNotFound,
+			},
+		},
+		{
+			name: "should return not found if order belongs to another user",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetOrder(gomock.Any(), "12345678903").
+					Return(&entity.Order{ID: "12345678903", UserID: 2, Status: OrderNew, UploadedAt: uploadedAt}, nil)
+			},
+			want: want{
+				err: ErrOrderNotFound,
+			},
+		},
+		{
+			name: "should return error if can't get order",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetOrder(gomock.Any(), "12345678903").
+					Return(nil, errInternal)
+			},
+			want: want{
+				err: errInternal,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			tt.prepare(storage)
+			service.storage = storage
+
+			ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+			result, err := service.GetUserOrder(ctx, "12345678903")
+
+			assert.Equal(t, tt.want.err, err)
+			assert.Equal(t, tt.want.order, result)
+		})
+	}
+}
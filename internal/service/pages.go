@@ -0,0 +1,112 @@
+package service
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// page turns a list request into a storage page. It asks for one row more
+// than the limit, which tells whether there is a next page.
+func page(req models.ListRequest) (entity.Page, error) {
+	p := entity.Page{
+		From: req.From,
+		To:   req.To,
+		Desc: req.Desc,
+	}
+
+	if req.Limit > 0 {
+		p.Limit = req.Limit + 1
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return p, err
+		}
+
+		p.After = cursor
+	}
+
+	return p, nil
+}
+
+// nextCursor returns the cursor of the page that follows rows, or an empty
+// string if rows is the last page. rows must hold up to limit+1 items, zero
+// limit means rows is the whole list.
+func nextCursor[T any](rows []T, limit int, cursor func(T) entity.Cursor) ([]T, string) {
+	if limit == 0 || len(rows) <= limit {
+		return rows, ""
+	}
+
+	rows = rows[:limit]
+
+	return rows, encodeCursor(cursor(rows[limit-1]))
+}
+
+// encodeCursor makes an opaque cursor out of a row position. It carries the
+// time with nanoseconds, so rows with the same second are not skipped.
+func encodeCursor(c entity.Cursor) string {
+	raw := c.At.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (*entity.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	at, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &entity.Cursor{At: t, ID: id}, nil
+}
+
+func orderCursor(order entity.Order) entity.Cursor {
+	return entity.Cursor{At: order.UploadedAt, ID: order.ID}
+}
+
+func withdrawalCursor(w entity.Withdraw) entity.Cursor {
+	return entity.Cursor{At: w.ProcessedAt, ID: strconv.Itoa(w.ID)}
+}
+
+// withdrawalFilter turns the withdrawal statuses of a list request into a
+// storage filter.
+func withdrawalFilter(p entity.Page, statuses []string) (entity.WithdrawalFilter, error) {
+	filter := entity.WithdrawalFilter{Page: p}
+
+	if p.After != nil {
+		if _, err := strconv.Atoi(p.After.ID); err != nil {
+			return filter, ErrInvalidCursor
+		}
+	}
+
+	var processed, reversed bool
+	for _, status := range statuses {
+		processed = processed || status == WithdrawalProcessed
+		reversed = reversed || status == WithdrawalReversed
+	}
+
+	if processed != reversed {
+		filter.Reversed = &reversed
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/auth"
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func Test_cursor(t *testing.T) {
+	c := entity.Cursor{At: time.Date(2023, 9, 22, 10, 0, 0, 123456000, time.UTC), ID: "12345678903"}
+
+	decoded, err := decodeCursor(encodeCursor(c))
+	require.NoError(t, err)
+	assert.Equal(t, c, *decoded)
+
+	for _, s := range []string{"%%%", "bm8tc2VwYXJhdG9y", "bm90LWEtdGltZXwx", "MjAyMy0wOS0yMlQxMDowMDowMFp8"} {
+		_, err := decodeCursor(s)
+		assert.ErrorIs(t, err, ErrInvalidCursor, s)
+	}
+}
+
+func Test_service_GetUserOrders_pages(t *testing.T) {
+	first := time.Date(2023, 9, 22, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+
+	service := service{
+		log: logger.NewLogger(),
+	}
+
+	ctrl := gomock.NewController(t)
+	storage := mocks.NewMockRepository(ctrl)
+	service.storage = storage
+
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+	storage.EXPECT().
+		GetUserOrders(gomock.Any(), 1, entity.OrderFilter{
+			Page:     entity.Page{Limit: 3, Desc: true},
+			Statuses: []string{OrderNew},
+		}).
+		Return([]entity.Order{
+			{ID: "1", Status: OrderNew, UploadedAt: first},
+			{ID: "2", Status: OrderNew, UploadedAt: second},
+			{ID: "3", Status: OrderNew, UploadedAt: second},
+		}, nil)
+
+	orders, next, err := service.GetUserOrders(ctx, models.ListRequest{Limit: 2, Statuses: []string{OrderNew}, Desc: true})
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, encodeCursor(entity.Cursor{At: second, ID: "2"}), next)
+
+	storage.EXPECT().
+		GetUserOrders(gomock.Any(), 1, entity.OrderFilter{
+			Page: entity.Page{Limit: 3, After: &entity.Cursor{At: second, ID: "2"}},
+		}).
+		Return([]entity.Order{
+			{ID: "3", Status: OrderNew, UploadedAt: second},
+		}, nil)
+
+	orders, next, err = service.GetUserOrders(ctx, models.ListRequest{Limit: 2, Cursor: next})
+	require.NoError(t, err)
+	assert.Len(t, orders, 1)
+	assert.Empty(t, next)
+
+	_, _, err = service.GetUserOrders(ctx, models.ListRequest{Limit: 2, Cursor: "bad"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	storage.EXPECT().
+		GetUserOrders(gomock.Any(), 1, entity.OrderFilter{}).
+		Return([]entity.Order{
+			{ID: "1", Status: OrderNew, UploadedAt: first},
+			{ID: "2", Status: OrderNew, UploadedAt: second},
+			{ID: "3", Status: OrderNew, UploadedAt: second},
+		}, nil)
+
+	orders, next, err = service.GetUserOrders(ctx, models.ListRequest{})
+	require.NoError(t, err)
+	assert.Len(t, orders, 3)
+	assert.Empty(t, next)
+}
+
+func Test_service_GetUserWithdrawals_filter(t *testing.T) {
+	processedAt := time.Date(2023, 9, 22, 10, 0, 0, 0, time.UTC)
+	yes, no := true, false
+
+	service := service{
+		log: logger.NewLogger(),
+	}
+
+	tests := []struct {
+		name     string
+		statuses []string
+		reversed *bool
+	}{
+		{
+			name: "should list all withdrawals if no status is given",
+		},
+		{
+			name:     "should list only processed withdrawals",
+			statuses: []string{WithdrawalProcessed},
+			reversed: &no,
+		},
+		{
+			name:     "should list only reversed withdrawals",
+			statuses: []string{WithdrawalReversed},
+			reversed: &yes,
+		},
+		{
+			name:     "should list all withdrawals if both statuses are given",
+			statuses: []string{WithdrawalReversed, WithdrawalProcessed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+			service.storage = storage
+
+			storage.EXPECT().
+				GetUserWithdrawals(gomock.Any(), 1, entity.WithdrawalFilter{
+					Page:     entity.Page{Limit: 2},
+					Reversed: tt.reversed,
+				}).
+				Return([]entity.Withdraw{
+					{ID: 7, OrderID: "12345678903", ProcessedAt: processedAt},
+					{ID: 8, OrderID: "2377225624", ProcessedAt: processedAt},
+				}, nil)
+
+			ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+			withdrawals, next, err := service.GetUserWithdrawals(ctx, models.ListRequest{Limit: 1, Statuses: tt.statuses})
+			require.NoError(t, err)
+			assert.Len(t, withdrawals, 1)
+			assert.Equal(t, encodeCursor(entity.Cursor{At: processedAt, ID: "7"}), next)
+		})
+	}
+}
+
+func Test_service_GetUserWithdrawals_invalidCursor(t *testing.T) {
+	service := service{
+		log: logger.NewLogger(),
+	}
+
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+	cursor := encodeCursor(entity.Cursor{At: time.Now(), ID: "12345678903x"})
+
+	_, _, err := service.GetUserWithdrawals(ctx, models.ListRequest{Limit: 1, Cursor: cursor})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
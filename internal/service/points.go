@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+const (
+	defaultPointsExpiryNotice = time.Hour * 24 * 30
+	pointsExpiryInterval      = time.Minute * 10
+	pointsExpiryPageSize      = 100
+)
+
+// getExpiringPoints returns the user's points that expire within the expiry
+// notice period.
+func (s *service) getExpiringPoints(ctx context.Context, userID int) (*entity.ExpiringPoints, error) {
+	return s.storage.GetExpiringPoints(ctx, userID, s.pointsTTL-s.pointsExpiryNotice)
+}
+
+// expirePoints writes off the points that were not spent within the points
+// TTL, a page of users at a time.
+func (s *service) expirePoints(ctx context.Context) error {
+	for {
+		users, err := s.storage.ExpirePoints(ctx, s.pointsTTL, pointsExpiryPageSize)
+		if err != nil {
+			return err
+		}
+
+		if users > 0 {
+			s.log.Info().Int("users", users).Msg("unspent points expired")
+		}
+
+		if users < pointsExpiryPageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/auth"
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func Test_service_GetBalance_expiringPoints(t *testing.T) {
+	earnedAt := time.Now().Add(-time.Hour * 24 * 350)
+
+	service := service{
+		log:                logger.NewLogger(),
+		pointsTTL:          time.Hour * 24 * 365,
+		pointsExpiryNotice: time.Hour * 24 * 30,
+	}
+
+	tests := []struct {
+		name     string
+		expiring *entity.ExpiringPoints
+		want     *models.BalanceResponse
+	}{
+		{
+			name: "should show points expiring soon",
+			expiring: &entity.ExpiringPoints{
+				UserID:   1,
+				Amount:   400,
+				EarnedAt: earnedAt,
+			},
+			want: &models.BalanceResponse{
+				Current:      1000,
+				ExpiringSoon: 400,
+				ExpiresAt:    earnedAt.Add(time.Hour * 24 * 365).Format(time.RFC3339),
+			},
+		},
+		{
+			name: "should leave expiry date out if nothing expires soon",
+			expiring: &entity.ExpiringPoints{
+				UserID: 1,
+			},
+			want: &models.BalanceResponse{
+				Current: 1000,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			storage.EXPECT().
+				GetBalance(gomock.Any(), 1).
+				Return(&entity.Balance{UserID: 1, Current: 1000}, nil)
+			storage.EXPECT().
+				CountUserOrdersByStatus(gomock.Any(), 1, unfinishedOrderStatuses).
+				Return(0, nil)
+			storage.EXPECT().
+				GetExpiringPoints(gomock.Any(), 1, time.Hour*24*335).
+				Return(tt.expiring, nil)
+
+			service.storage = storage
+
+			ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+			result, err := service.GetBalance(ctx)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func Test_service_expirePoints(t *testing.T) {
+	service := service{
+		log:       logger.NewLogger(),
+		pointsTTL: time.Hour,
+	}
+
+	ctrl := gomock.NewController(t)
+	storage := mocks.NewMockRepository(ctrl)
+	service.storage = storage
+
+	gomock.InOrder(
+		storage.EXPECT().ExpirePoints(gomock.Any(), time.Hour, pointsExpiryPageSize).Return(pointsExpiryPageSize, nil),
+		storage.EXPECT().ExpirePoints(gomock.Any(), time.Hour, pointsExpiryPageSize).Return(0, nil),
+	)
+
+	err := service.expirePoints(context.Background())
+	assert.NoError(t, err)
+}
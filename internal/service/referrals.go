@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+const (
+	referralCodeBytes    = 5
+	referralCodeAttempts = 3
+)
+
+func (s *service) GetReferral(ctx context.Context) (*models.ReferralResponse, error) {
+	userID, err := extractUserIDFromCtx(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to extracrt user from context")
+		return nil, err
+	}
+
+	stats, err := s.storage.GetReferralStats(ctx, userID)
+	if err != nil {
+		s.log.Error().Err(err).Int("user", userID).Msg("failed to get user's referrals")
+		return nil, err
+	}
+
+	return &models.ReferralResponse{
+		Code:     stats.Code,
+		Referred: stats.Referred,
+		Rewarded: stats.Rewarded,
+	}, nil
+}
+
+// findReferrer returns the id of the owner of the referral code the user
+// registers with.
+func (s *service) findReferrer(ctx context.Context, userReq models.UserRequest) (int, error) {
+	code := normalizeReferralCode(userReq.ReferralCode)
+
+	referrer, err := s.storage.GetUserByReferralCode(ctx, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.log.Info().Str("login", userReq.Login).Str("code", code).Msg(ErrReferralCodeNotFound.Error())
+		return 0, ErrReferralCodeNotFound
+	}
+
+	if err != nil {
+		s.log.Error().Err(err).Str("login", userReq.Login).Msg("failed to find referrer")
+		return 0, err
+	}
+
+	return referrer.ID, nil
+}
+
+// saveUserWithReferralCode saves the user with a new referral code, picking
+// another one in the unlikely case the code is taken.
+func (s *service) saveUserWithReferralCode(ctx context.Context, user entity.User) (int, error) {
+	var err error
+	for i := 0; i < referralCodeAttempts; i++ {
+		user.ReferralCode, err = generateReferralCode()
+		if err != nil {
+			return 0, err
+		}
+
+		var userID int
+		userID, err = s.storage.SaveUser(ctx, user)
+		if !errors.Is(err, storage.ErrReferralCodeExists) {
+			return userID, err
+		}
+	}
+
+	return 0, err
+}
+
+func generateReferralCode() (string, error) {
+	b := make([]byte, referralCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base32.StdEncoding.EncodeToString(b), nil
+}
+
+func normalizeReferralCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
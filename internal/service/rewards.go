@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func (s *service) CreateReward(ctx context.Context, req models.RewardRequest) (*models.RewardResponse, error) {
+	reward, err := s.storage.SaveReward(ctx, rewardEntity(req))
+	if err != nil {
+		s.log.Error().Err(err).Str("reward", req.Name).Msg("failed to save reward")
+		return nil, err
+	}
+
+	resp := rewardResponse(*reward)
+
+	return &resp, nil
+}
+
+func (s *service) UpdateReward(ctx context.Context, rewardID int, req models.RewardRequest) (*models.RewardResponse, error) {
+	r := rewardEntity(req)
+	r.ID = rewardID
+
+	reward, err := s.storage.UpdateReward(ctx, r)
+	if errors.Is(err, storage.ErrRewardNotFound) {
+		s.log.Info().Int("reward", rewardID).Msg(ErrRewardNotFound.Error())
+		return nil, ErrRewardNotFound
+	}
+
+	if err != nil {
+		s.log.Error().Err(err).Int("reward", rewardID).Msg("failed to update reward")
+		return nil, err
+	}
+
+	resp := rewardResponse(*reward)
+
+	return &resp, nil
+}
+
+// GetRewards returns the rewards users can redeem, or every reward of the
+// catalog if all is set.
+func (s *service) GetRewards(ctx context.Context, all bool) ([]models.RewardResponse, error) {
+	rewards, err := s.storage.GetRewards(ctx, all)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to get rewards")
+		return nil, err
+	}
+
+	resp := make([]models.RewardResponse, 0, len(rewards))
+	for _, r := range rewards {
+		resp = append(resp, rewardResponse(r))
+	}
+
+	return resp, nil
+}
+
+func (s *service) Redeem(ctx context.Context, rewardID int) (*models.RedemptionResponse, error) {
+	userID, err := extractUserIDFromCtx(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to extracrt user from context")
+		return nil, err
+	}
+
+	redemption, err := s.storage.Redeem(ctx, userID, rewardID)
+	if errors.Is(err, storage.ErrRewardNotFound) {
+		s.log.Info().Int("user", userID).Int("reward", rewardID).Msg(ErrRewardNotFound.Error())
+		return nil, ErrRewardNotFound
+	}
+
+	if errors.Is(err, storage.ErrRewardOutOfStock) {
+		s.log.Info().Int("user", userID).Int("reward", rewardID).Msg(ErrRewardOutOfStock.Error())
+		return nil, ErrRewardOutOfStock
+	}
+
+	if errors.Is(err, storage.ErrInsufficientFunds) {
+		s.log.Info().Int("user", userID).Int("reward", rewardID).Msg(ErrBalanceNotEnough.Error())
+		return nil, ErrBalanceNotEnough
+	}
+
+	if err != nil {
+		s.log.Error().Err(err).Int("user", userID).Int("reward", rewardID).Msg("failed to redeem reward")
+		return nil, err
+	}
+
+	s.log.Info().Int("user", userID).Int("reward", rewardID).Int("price", redemption.Price).Msg("reward was redeemed")
+
+	resp := redemptionResponse(*redemption)
+
+	return &resp, nil
+}
+
+func (s *service) GetUserRedemptions(ctx context.Context) ([]models.RedemptionResponse, error) {
+	userID, err := extractUserIDFromCtx(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to extracrt user from context")
+		return nil, err
+	}
+
+	redemptions, err := s.storage.GetUserRedemptions(ctx, userID)
+	if err != nil {
+		s.log.Error().Err(err).Int("user", userID).Msg("failed to get user's redemptions")
+		return nil, err
+	}
+
+	resp := make([]models.RedemptionResponse, 0, len(redemptions))
+	for _, r := range redemptions {
+		resp = append(resp, redemptionResponse(r))
+	}
+
+	return resp, nil
+}
+
+func rewardEntity(req models.RewardRequest) entity.Reward {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	return entity.Reward{
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       int(req.Price),
+		Stock:       req.Stock,
+		Active:      active,
+	}
+}
+
+func rewardResponse(r entity.Reward) models.RewardResponse {
+	return models.RewardResponse{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		Price:       models.Amount(r.Price),
+		Stock:       r.Stock,
+		Active:      r.Active,
+	}
+}
+
+func redemptionResponse(r entity.Redemption) models.RedemptionResponse {
+	return models.RedemptionResponse{
+		ID:         r.ID,
+		RewardID:   r.RewardID,
+		Name:       r.RewardName,
+		Price:      models.Amount(r.Price),
+		RedeemedAt: r.RedeemedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/auth"
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func Test_service_Redeem(t *testing.T) {
+	redeemedAt := time.Date(2023, 9, 20, 12, 0, 0, 0, time.UTC)
+
+	type want struct {
+		redemption *models.RedemptionResponse
+		err        error
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		prepare func(s *mocks.MockRepository)
+		want    want
+	}{
+		{
+			name: "should redeem reward",
+			ctx:  context.WithValue(context.Background(), auth.UserIDKey, 1),
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					Redeem(gomock.Any(), 1, 7).
+					Return(&entity.Redemption{
+						ID:         3,
+						UserID:     1,
+						RewardID:   7,
+						RewardName: "mug",
+						Price:      50000,
+						RedeemedAt: redeemedAt,
+					}, nil)
+			},
+			want: want{
+				redemption: &models.RedemptionResponse{
+					ID:         3,
+					RewardID:   7,
+					Name:       "mug",
+					Price:      50000,
+					RedeemedAt: redeemedAt.Format(time.RFC3339),
+				},
+			},
+		},
+		{
+			name: "should return error when reward doesn't exist",
+			ctx:  context.WithValue(context.Background(), auth.UserIDKey, 1),
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					Redeem(gomock.Any(), 1, 7).
+					Return(nil, storage.ErrRewardNotFound)
+			},
+			want: want{
+				err: ErrRewardNotFound,
+			},
+		},
+		{
+			name: "should return error when reward is out of stock",
+			ctx:  context.WithValue(context.Background(), auth.UserIDKey, 1),
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					Redeem(gomock.Any(), 1, 7).
+					Return(nil, storage.ErrRewardOutOfStock)
+			},
+			want: want{
+				err: ErrRewardOutOfStock,
+			},
+		},
+		{
+			name: "should return error when balance is not enough",
+			ctx:  context.WithValue(context.Background(), auth.UserIDKey, 1),
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					Redeem(gomock.Any(), 1, 7).
+					Return(nil, storage.ErrInsufficientFunds)
+			},
+			want: want{
+				err: ErrBalanceNotEnough,
+			},
+		},
+		{
+			name:    "should return error if user is not in context",
+			ctx:     context.WithValue(context.Background(), badContextKey("bad_key"), 1),
+			prepare: func(s *mocks.MockRepository) {},
+			want: want{
+				err: ErrExtractFromContext,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			tt.prepare(storage)
+
+			service := service{
+				log:     logger.NewLogger(),
+				storage: storage,
+			}
+
+			redemption, err := service.Redeem(tt.ctx, 7)
+
+			assert.ErrorIs(t, err, tt.want.err)
+			assert.Equal(t, tt.want.redemption, redemption)
+		})
+	}
+}
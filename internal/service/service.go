@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"database/sql"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -13,6 +14,7 @@ import (
 	"github.com/PrahaTurbo/gophermart/internal/models"
 	"github.com/PrahaTurbo/gophermart/internal/storage"
 	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+	"github.com/PrahaTurbo/gophermart/internal/validator"
 )
 
 type Service interface {
@@ -20,37 +22,181 @@ type Service interface {
 	LoginUser(ctx context.Context, userReq models.UserRequest) (int, error)
 
 	ProcessOrder(ctx context.Context, orderID string) error
-	GetUserOrders(ctx context.Context) ([]models.OrderResponse, error)
+	ProcessOrders(ctx context.Context, orderIDs []string) ([]models.OrderUploadResult, error)
+	GetUserOrders(ctx context.Context, req models.ListRequest) ([]models.OrderResponse, string, error)
+	GetUserOrder(ctx context.Context, orderID string) (*models.OrderResponse, error)
+	GetPendingOrders(ctx context.Context) ([]models.OrderResponse, error)
+
+	GetReferral(ctx context.Context) (*models.ReferralResponse, error)
 
 	GetBalance(ctx context.Context) (*models.BalanceResponse, error)
+	GetBalanceHistory(ctx context.Context, from, to time.Time) ([]models.BalanceHistoryResponse, error)
 
 	Withdraw(ctx context.Context, req models.WithdrawRequest) error
-	GetUserWithdrawals(ctx context.Context) ([]models.WithdrawalsResponse, error)
+	GetUserWithdrawals(ctx context.Context, req models.ListRequest) ([]models.WithdrawalsResponse, string, error)
+	ReverseWithdrawal(ctx context.Context, orderID string) (*models.WithdrawalsResponse, error)
+	Transfer(ctx context.Context, req models.TransferRequest) error
+
+	HoldPoints(ctx context.Context, req models.HoldRequest) (*models.HoldResponse, error)
+	CaptureHold(ctx context.Context, orderID string) (*models.HoldResponse, error)
+	ReleaseHold(ctx context.Context, orderID string) (*models.HoldResponse, error)
+
+	CreateReward(ctx context.Context, req models.RewardRequest) (*models.RewardResponse, error)
+	UpdateReward(ctx context.Context, rewardID int, req models.RewardRequest) (*models.RewardResponse, error)
+	GetRewards(ctx context.Context, all bool) ([]models.RewardResponse, error)
+	Redeem(ctx context.Context, rewardID int) (*models.RedemptionResponse, error)
+	GetUserRedemptions(ctx context.Context) ([]models.RedemptionResponse, error)
+
+	CreateCampaign(ctx context.Context, req models.CampaignRequest) (*models.CampaignResponse, error)
+	GetCampaigns(ctx context.Context) ([]models.CampaignResponse, error)
+
+	BeginIdempotentRequest(ctx context.Context, key string, request []byte) (*models.IdempotentResponse, error)
+	CompleteIdempotentRequest(ctx context.Context, userID int, key string, resp models.IdempotentResponse) error
+	ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error
+
+	Shutdown(ctx context.Context) error
+}
+
+type Config struct {
+	AccrualWorkers int
+	AccrualMaxAge  time.Duration
+	HoldTTL        time.Duration
+
+	// PointsTTL is how long earned points stay valid, zero keeps them
+	// forever. The balance warns about points expiring within
+	// PointsExpiryNotice.
+	PointsTTL          time.Duration
+	PointsExpiryNotice time.Duration
+
+	// TransferLimit caps a single transfer and TransferDailyLimit the sum a
+	// user can transfer within 24 hours. Zero means no limit.
+	TransferLimit      models.Amount
+	TransferDailyLimit models.Amount
+
+	// Tiers are the loyalty tiers as returned by ParseTiers, none disables
+	// them.
+	Tiers []Tier
+
+	// ReferralBonus is what both a referrer and the user they referred get
+	// once the user's first order is processed. A referrer is rewarded for at
+	// most ReferralLimit referrals, zero means no limit.
+	ReferralBonus models.Amount
+	ReferralLimit int
 }
 
 type service struct {
-	log                logger.Logger
-	storage            storage.Repository
-	accrualClient      *client.AccrualClient
-	accrualUpdaterChan chan entity.Order
+	log           logger.Logger
+	storage       storage.Repository
+	accrualClient *client.AccrualClient
+	accrualMaxAge time.Duration
+	holdTTL       time.Duration
+
+	pointsTTL          time.Duration
+	pointsExpiryNotice time.Duration
+
+	transferLimit      models.Amount
+	transferDailyLimit models.Amount
+
+	tiers []Tier
+
+	referralBonus models.Amount
+	referralLimit int
+
+	accrualJobs    chan entity.AccrualJob
+	workersWG      sync.WaitGroup
+	tasksWG        sync.WaitGroup
+	stopUpdater    context.CancelFunc
+	updaterStopped chan struct{}
 }
 
 func NewService(
 	storage storage.Repository,
 	accrualClient *client.AccrualClient,
 	logger logger.Logger,
+	cfg Config,
 ) Service {
-	s := service{
-		log:                logger,
-		storage:            storage,
-		accrualClient:      accrualClient,
-		accrualUpdaterChan: make(chan entity.Order, 20),
+	if cfg.AccrualWorkers < 1 {
+		cfg.AccrualWorkers = 1
+	}
+
+	if cfg.AccrualMaxAge <= 0 {
+		cfg.AccrualMaxAge = defaultAccrualMaxAge
+	}
+
+	if cfg.HoldTTL <= 0 {
+		cfg.HoldTTL = defaultHoldTTL
+	}
+
+	if cfg.PointsExpiryNotice <= 0 {
+		cfg.PointsExpiryNotice = defaultPointsExpiryNotice
+	}
+
+	s := &service{
+		log:           logger,
+		storage:       storage,
+		accrualClient: accrualClient,
+		accrualMaxAge: cfg.AccrualMaxAge,
+		holdTTL:       cfg.HoldTTL,
+
+		pointsTTL:          cfg.PointsTTL,
+		pointsExpiryNotice: cfg.PointsExpiryNotice,
+
+		transferLimit:      cfg.TransferLimit,
+		transferDailyLimit: cfg.TransferDailyLimit,
+		tiers:              cfg.Tiers,
+		referralBonus:      cfg.ReferralBonus,
+		referralLimit:      cfg.ReferralLimit,
+		accrualJobs:        make(chan entity.AccrualJob, cfg.AccrualWorkers),
+		updaterStopped:     make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopUpdater = cancel
+
+	s.startAccrualWorkers(ctx, cfg.AccrualWorkers)
+
+	go func() {
+		defer close(s.updaterStopped)
+
+		s.log.Info().Msg("recovering unfinished orders")
+		if err := s.recoverUnfinishedOrders(ctx, 1000); err != nil {
+			s.log.Error().Err(err).Msg("failed to recover unfinished orders")
+		}
+
+		s.log.Info().Int("workers", cfg.AccrualWorkers).Msg("starting accrual updater")
+		s.startAccrualUpdater(ctx, time.Second*3)
+	}()
+
+	s.runPeriodically(ctx, "hold expirer", holdExpiryInterval, s.expireHolds)
+	s.runPeriodically(ctx, "idempotency key cleaner", idempotencyCleanupInterval, s.deleteExpiredIdempotencyKeys)
+
+	if cfg.PointsTTL > 0 {
+		s.runPeriodically(ctx, "points expirer", pointsExpiryInterval, s.expirePoints)
 	}
 
-	s.log.Info().Msg("starting accrual updater")
-	go s.startAccrualUpdater(time.Second*3, 50)
+	return s
+}
 
-	return &s
+// Shutdown stops claiming new accrual jobs and waits until the workers have
+// finished the jobs they already hold and the periodic tasks have returned.
+func (s *service) Shutdown(ctx context.Context) error {
+	s.stopUpdater()
+
+	done := make(chan struct{})
+	go func() {
+		<-s.updaterStopped
+		s.workersWG.Wait()
+		s.tasksWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.log.Info().Msg("accrual updater stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (s *service) CreateUser(ctx context.Context, userReq models.UserRequest) (int, error) {
@@ -64,7 +210,16 @@ func (s *service) CreateUser(ctx context.Context, userReq models.UserRequest) (i
 		PasswordHash: string(passHash),
 	}
 
-	userID, err := s.storage.SaveUser(ctx, user)
+	if userReq.ReferralCode != "" {
+		referrerID, err := s.findReferrer(ctx, userReq)
+		if err != nil {
+			return 0, err
+		}
+
+		user.ReferrerID = referrerID
+	}
+
+	userID, err := s.saveUserWithReferralCode(ctx, user)
 	if err != nil {
 		s.log.Error().Err(err).Str("login", user.Login).Msg("failed to save user in database")
 		return 0, err
@@ -75,7 +230,7 @@ func (s *service) CreateUser(ctx context.Context, userReq models.UserRequest) (i
 		return 0, err
 	}
 
-	s.log.Info().Int("user", userID).Msg("user was created")
+	s.log.Info().Int("user", userID).Int("referrer", user.ReferrerID).Msg("user was created")
 	return userID, nil
 }
 
@@ -102,7 +257,7 @@ func (s *service) ProcessOrder(ctx context.Context, orderID string) error {
 		return err
 	}
 
-	isValidLuhn := validateLuhn(orderID)
+	isValidLuhn := validator.Luhn(orderID)
 	if !isValidLuhn {
 		s.log.Error().Err(err).Str("order", orderID).Send()
 		return ErrInvalidOrderID
@@ -137,44 +292,83 @@ func (s *service) ProcessOrder(ctx context.Context, orderID string) error {
 		return saveErr
 	}
 
-	s.accrualUpdaterChan <- *order
-
-	s.log.Info().Any("order", order).Msg("order was placed in update channel")
+	s.log.Info().Any("order", order).Msg("order was queued for accrual update")
 
 	return nil
 }
 
-func (s *service) GetUserOrders(ctx context.Context) ([]models.OrderResponse, error) {
+// GetUserOrders returns a page of the user's orders along with the cursor of
+// the next page, empty if this is the last one.
+func (s *service) GetUserOrders(ctx context.Context, req models.ListRequest) ([]models.OrderResponse, string, error) {
 	userID, err := extractUserIDFromCtx(ctx)
 	if err != nil {
 		s.log.Error().Err(err).Msg("failed to extracrt user from context")
-		return nil, err
+		return nil, "", err
+	}
+
+	p, err := page(req)
+	if err != nil {
+		s.log.Info().Int("user", userID).Str("cursor", req.Cursor).Msg(err.Error())
+		return nil, "", err
 	}
 
-	orders, err := s.storage.GetUserOrders(ctx, userID)
+	orders, err := s.storage.GetUserOrders(ctx, userID, entity.OrderFilter{Page: p, Statuses: req.Statuses})
 	if err != nil {
 		s.log.Error().Err(err).Int("user", userID).Msg("failed to get user's orders")
-		return nil, err
+		return nil, "", err
 	}
 
+	orders, next := nextCursor(orders, req.Limit, orderCursor)
+
 	resp := make([]models.OrderResponse, len(orders))
 	for i, order := range orders {
-		o := models.OrderResponse{
-			ID:         order.ID,
-			Status:     order.Status,
-			UploadedAt: order.UploadedAt.Format(time.RFC3339),
-		}
+		resp[i] = orderResponse(order)
+	}
 
-		if order.Status == OrderProcessed {
-			o.Accrual = amountToFloat64(order.Accrual)
-		}
+	return resp, next, nil
+}
+
+// GetPendingOrders lists the user's orders whose accrual is still being
+// processed, the orders behind the pending figure of the balance.
+func (s *service) GetPendingOrders(ctx context.Context) ([]models.OrderResponse, error) {
+	userID, err := extractUserIDFromCtx(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to extracrt user from context")
+		return nil, err
+	}
+
+	orders, err := s.storage.GetUserOrdersByStatus(ctx, userID, unfinishedOrderStatuses)
+	if err != nil {
+		s.log.Error().Err(err).Int("user", userID).Msg("failed to get user's pending orders")
+		return nil, err
+	}
 
-		resp[i] = o
+	resp := make([]models.OrderResponse, len(orders))
+	for i, order := range orders {
+		resp[i] = orderResponse(order)
 	}
 
 	return resp, nil
 }
 
+func orderResponse(order entity.Order) models.OrderResponse {
+	o := models.OrderResponse{
+		ID:         order.ID,
+		Status:     order.Status,
+		UploadedAt: order.UploadedAt.Format(time.RFC3339),
+	}
+
+	if order.Status == OrderProcessed {
+		o.Accrual = models.Amount(order.Accrual)
+	}
+
+	if !order.CreditedAt.IsZero() {
+		o.CreditedAt = order.CreditedAt.Format(time.RFC3339)
+	}
+
+	return o
+}
+
 func (s *service) GetBalance(ctx context.Context) (*models.BalanceResponse, error) {
 	userID, err := extractUserIDFromCtx(ctx)
 	if err != nil {
@@ -188,9 +382,67 @@ func (s *service) GetBalance(ctx context.Context) (*models.BalanceResponse, erro
 		return nil, err
 	}
 
+	pending, err := s.storage.CountUserOrdersByStatus(ctx, userID, unfinishedOrderStatuses)
+	if err != nil {
+		s.log.Error().Err(err).Int("user", userID).Msg("failed to get user's pending orders")
+		return nil, err
+	}
+
 	resp := &models.BalanceResponse{
-		Current:   amountToFloat64(balance.Current),
-		Withdrawn: amountToFloat64(balance.Withdrawn),
+		Current:   models.Amount(balance.Current),
+		Reserved:  models.Amount(balance.Reserved),
+		Withdrawn: models.Amount(balance.Withdrawn),
+		Pending:   pending,
+	}
+
+	if s.pointsTTL > 0 {
+		expiring, err := s.getExpiringPoints(ctx, userID)
+		if err != nil {
+			s.log.Error().Err(err).Int("user", userID).Msg("failed to get user's expiring points")
+			return nil, err
+		}
+
+		if expiring.Amount > 0 {
+			resp.ExpiringSoon = models.Amount(expiring.Amount)
+			resp.ExpiresAt = expiring.EarnedAt.Add(s.pointsTTL).Format(time.RFC3339)
+		}
+	}
+
+	if len(s.tiers) > 0 {
+		lifetime, err := s.storage.GetLifetimeAccrual(ctx, userID)
+		if err != nil {
+			s.log.Error().Err(err).Int("user", userID).Msg("failed to get user's lifetime accrual")
+			return nil, err
+		}
+
+		resp.Tier = s.tierFor(lifetime).Name
+	}
+
+	return resp, nil
+}
+
+func (s *service) GetBalanceHistory(ctx context.Context, from, to time.Time) ([]models.BalanceHistoryResponse, error) {
+	userID, err := extractUserIDFromCtx(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to extracrt user from context")
+		return nil, err
+	}
+
+	entries, err := s.storage.GetBalanceHistory(ctx, userID, from, to)
+	if err != nil {
+		s.log.Error().Err(err).Int("user", userID).Msg("failed to get user's balance history")
+		return nil, err
+	}
+
+	resp := make([]models.BalanceHistoryResponse, len(entries))
+	for i, entry := range entries {
+		resp[i] = models.BalanceHistoryResponse{
+			Type:      entry.Kind,
+			Reference: entry.Reference,
+			Amount:    models.Amount(entry.Amount),
+			Balance:   models.Amount(entry.BalanceAfter),
+			CreatedAt: entry.CreatedAt.Format(time.RFC3339),
+		}
 	}
 
 	return resp, nil
@@ -203,30 +455,35 @@ func (s *service) Withdraw(ctx context.Context, req models.WithdrawRequest) erro
 		return err
 	}
 
-	isValidLuhn := validateLuhn(req.Order)
+	isValidLuhn := validator.Luhn(req.Order)
 	if !isValidLuhn {
 		s.log.Error().Err(err).Str("order", req.Order).Send()
 		return ErrInvalidOrderID
 	}
 
+	if req.Sum <= 0 {
+		s.log.Error().Str("sum", req.Sum.String()).Int("user", userID).Msg(ErrInvalidWithdrawSum.Error())
+		return ErrInvalidWithdrawSum
+	}
+
 	withdraw := entity.Withdraw{
 		UserID:  userID,
 		OrderID: req.Order,
-		Sum:     amountToInt(req.Sum),
+		Sum:     int(req.Sum),
 	}
 
-	balance, err := s.storage.GetBalance(ctx, userID)
-	if err != nil {
-		s.log.Error().Err(err).Int("user", userID).Msg("failed to get user's balance")
-		return err
+	err = s.storage.Withdraw(ctx, withdraw)
+	if errors.Is(err, storage.ErrInsufficientFunds) {
+		s.log.Info().Int("user", userID).Int("sum", withdraw.Sum).Msg(ErrBalanceNotEnough.Error())
+		return ErrBalanceNotEnough
 	}
 
-	if balance.Current < withdraw.Sum {
-		s.log.Error().Err(err).Int("user", userID).Send()
-		return ErrBalanceNotEnough
+	if errors.Is(err, storage.ErrWithdrawalOrderExists) {
+		s.log.Info().Int("user", userID).Str("order", withdraw.OrderID).Msg(ErrWithdrawalOrderUsed.Error())
+		return ErrWithdrawalOrderUsed
 	}
 
-	if err := s.storage.Withdraw(ctx, withdraw); err != nil {
+	if err != nil {
 		s.log.Error().Err(err).Int("user", userID).Msg("failed to withdraw from balance")
 		return err
 	}
@@ -236,86 +493,76 @@ func (s *service) Withdraw(ctx context.Context, req models.WithdrawRequest) erro
 	return nil
 }
 
-func (s *service) GetUserWithdrawals(ctx context.Context) ([]models.WithdrawalsResponse, error) {
+// GetUserWithdrawals returns a page of the user's withdrawals along with the
+// cursor of the next page, empty if this is the last one.
+func (s *service) GetUserWithdrawals(ctx context.Context, req models.ListRequest) ([]models.WithdrawalsResponse, string, error) {
 	userID, err := extractUserIDFromCtx(ctx)
 	if err != nil {
 		s.log.Error().Err(err).Msg("failed to extracrt user from context")
-		return nil, err
+		return nil, "", err
+	}
+
+	p, err := page(req)
+	if err != nil {
+		s.log.Info().Int("user", userID).Str("cursor", req.Cursor).Msg(err.Error())
+		return nil, "", err
+	}
+
+	filter, err := withdrawalFilter(p, req.Statuses)
+	if err != nil {
+		s.log.Info().Int("user", userID).Str("cursor", req.Cursor).Msg(err.Error())
+		return nil, "", err
 	}
 
-	withdrawals, err := s.storage.GetUserWithdrawals(ctx, userID)
+	withdrawals, err := s.storage.GetUserWithdrawals(ctx, userID, filter)
 	if err != nil {
 		s.log.Error().Err(err).Int("user", userID).Msg("failed to get user's withdrawals")
-		return nil, err
+		return nil, "", err
 	}
 
+	withdrawals, next := nextCursor(withdrawals, req.Limit, withdrawalCursor)
+
 	resp := make([]models.WithdrawalsResponse, len(withdrawals))
 	for i, withdraw := range withdrawals {
-		w := models.WithdrawalsResponse{
-			Order:       withdraw.OrderID,
-			Sum:         amountToFloat64(withdraw.Sum),
-			ProcessedAt: withdraw.ProcessedAt.Format(time.RFC3339),
-		}
-
-		resp[i] = w
+		resp[i] = withdrawalResponse(withdraw)
 	}
 
-	return resp, nil
+	return resp, next, nil
 }
 
-func (s *service) startAccrualUpdater(interval time.Duration, batchSize int) {
-	ticker := time.NewTicker(interval)
-
-	var orders []entity.Order
-
-	for {
-		select {
-		case order := <-s.accrualUpdaterChan:
-			orders = append(orders, order)
-
-			if len(orders) >= batchSize {
-				s.handleAccrualUpdater(orders)
-				orders = nil
-			}
-		case <-ticker.C:
-			if len(orders) > 0 {
-				s.handleAccrualUpdater(orders)
-				orders = nil
-			}
-		}
+// ReverseWithdrawal returns the points paid for the order back to the user.
+// Reversing an already reversed withdrawal is a no-op.
+func (s *service) ReverseWithdrawal(ctx context.Context, orderID string) (*models.WithdrawalsResponse, error) {
+	withdraw, err := s.storage.ReverseWithdrawal(ctx, orderID)
+	if errors.Is(err, storage.ErrWithdrawalNotFound) {
+		s.log.Info().Str("order", orderID).Msg(ErrWithdrawalNotFound.Error())
+		return nil, ErrWithdrawalNotFound
+	}
+
+	if err != nil {
+		s.log.Error().Err(err).Str("order", orderID).Msg("failed to reverse withdrawal")
+		return nil, err
 	}
+
+	s.log.Info().Int("user", withdraw.UserID).Str("order", orderID).Msg("withdrawal was reversed")
+
+	resp := withdrawalResponse(*withdraw)
+
+	return &resp, nil
 }
 
-func (s *service) handleAccrualUpdater(orders []entity.Order) {
-	const maxRetryCount = 3
-
-	for _, order := range orders {
-		go func(order entity.Order) {
-			for retryCount := 0; retryCount < maxRetryCount; retryCount++ {
-				resp, err := s.accrualClient.GetAccrual(order.ID)
-				if err != nil {
-					s.log.Error().Err(err).Str("order_id", order.ID).Int("attempt", retryCount+1).Msg("failed to get accrual information")
-					continue
-				}
-
-				order.Accrual = amountToInt(resp.Accrual)
-				order.Status = resp.Status
-
-				if err := s.storage.UpdateOrder(order); err != nil {
-					s.log.Error().Err(err).Str("order_id", order.ID).Msg("failed to update order")
-				}
-
-				switch order.Status {
-				case OrderProcessed:
-					if err := s.storage.UpdateBalance(order.Accrual, order.UserID); err != nil {
-						s.log.Error().Err(err).Str("order_id", order.ID).Msg("failed to update balance")
-					}
-				case OrderRegistered, OrderProcessing:
-					s.accrualUpdaterChan <- order
-				}
-
-				break
-			}
-		}(order)
+func withdrawalResponse(withdraw entity.Withdraw) models.WithdrawalsResponse {
+	w := models.WithdrawalsResponse{
+		Order:       withdraw.OrderID,
+		Sum:         models.Amount(withdraw.Sum),
+		Status:      WithdrawalProcessed,
+		ProcessedAt: withdraw.ProcessedAt.Format(time.RFC3339),
+	}
+
+	if !withdraw.ReversedAt.IsZero() {
+		w.Status = WithdrawalReversed
+		w.ReversedAt = withdraw.ReversedAt.Format(time.RFC3339)
 	}
+
+	return w
 }
@@ -76,6 +76,77 @@ func Test_service_CreateUser(t *testing.T) {
 				err:    storage.ErrAlreadyExist,
 			},
 		},
+		{
+			name: "should save referrer of referral code",
+			userReq: models.UserRequest{
+				Login:        "test",
+				Password:     "test_password",
+				ReferralCode: " abcdefgh ",
+			},
+			prepare: func(s *mocks.MockRepository) {
+				gomock.InOrder(
+					s.EXPECT().
+						GetUserByReferralCode(gomock.Any(), "ABCDEFGH").
+						Return(&entity.User{ID: 5, ReferralCode: "ABCDEFGH"}, nil),
+					s.EXPECT().
+						SaveUser(gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, user entity.User) (int, error) {
+							if user.ReferrerID != 5 || user.ReferralCode == "" {
+								return 0, errInternal
+							}
+							return 1, nil
+						}),
+					s.EXPECT().
+						CreateBalance(gomock.Any(), 1).
+						Return(nil),
+				)
+			},
+			want: want{
+				userID: 1,
+				err:    nil,
+			},
+		},
+		{
+			name: "should return error if referral code doesn't exist",
+			userReq: models.UserRequest{
+				Login:        "test",
+				Password:     "test_password",
+				ReferralCode: "ABCDEFGH",
+			},
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetUserByReferralCode(gomock.Any(), "ABCDEFGH").
+					Return(nil, sql.ErrNoRows)
+			},
+			want: want{
+				userID: 0,
+				err:    ErrReferralCodeNotFound,
+			},
+		},
+		{
+			name: "should retry when referral code is taken",
+			userReq: models.UserRequest{
+				Login:    "test",
+				Password: "test_password",
+			},
+			prepare: func(s *mocks.MockRepository) {
+				gomock.InOrder(
+					s.EXPECT().
+						SaveUser(gomock.Any(), gomock.Any()).
+						Return(0, storage.ErrReferralCodeExists),
+					s.EXPECT().
+						SaveUser(gomock.Any(), gomock.Any()).
+						Return(1, nil),
+					s.EXPECT().
+						CreateBalance(gomock.Any(), 1).
+						Return(nil),
+				)
+			},
+			want: want{
+				userID: 1,
+				err:    nil,
+			},
+		},
 		{
 			name: "should return error if failed to create balance",
 			userReq: models.UserRequest{
@@ -211,13 +282,11 @@ func Test_service_LoginUser(t *testing.T) {
 
 func Test_service_ProcessOrder(t *testing.T) {
 	service := service{
-		log:                logger.NewLogger(),
-		accrualUpdaterChan: make(chan entity.Order, 20),
+		log: logger.NewLogger(),
 	}
 
 	type want struct {
-		orderID string
-		err     error
+		err error
 	}
 
 	tests := []struct {
@@ -244,8 +313,7 @@ func Test_service_ProcessOrder(t *testing.T) {
 				)
 			},
 			want: want{
-				orderID: "12345678903",
-				err:     nil,
+				err: nil,
 			},
 		},
 		{
@@ -345,12 +413,6 @@ func Test_service_ProcessOrder(t *testing.T) {
 			}
 
 			err := service.ProcessOrder(ctx, tt.orderID)
-
-			if err == nil {
-				res := <-service.accrualUpdaterChan
-				assert.Equal(t, tt.want.orderID, res.ID)
-			}
-
 			assert.Equal(t, tt.want.err, err)
 		})
 	}
@@ -377,7 +439,7 @@ func Test_service_GetUserOrders(t *testing.T) {
 			name: "should successfully return user orders",
 			prepare: func(s *mocks.MockRepository) {
 				s.EXPECT().
-					GetUserOrders(gomock.Any(), 1).
+					GetUserOrders(gomock.Any(), 1, gomock.Any()).
 					Return([]entity.Order{
 						{
 							ID:         "12345678903",
@@ -391,7 +453,7 @@ func Test_service_GetUserOrders(t *testing.T) {
 				ordersResp: []models.OrderResponse{
 					{
 						ID:         "12345678903",
-						Accrual:    134,
+						Accrual:    13400,
 						Status:     OrderProcessed,
 						UploadedAt: now.Format(time.RFC3339),
 					},
@@ -403,7 +465,7 @@ func Test_service_GetUserOrders(t *testing.T) {
 			name: "shouldn't return accrual if status not processed",
 			prepare: func(s *mocks.MockRepository) {
 				s.EXPECT().
-					GetUserOrders(gomock.Any(), 1).
+					GetUserOrders(gomock.Any(), 1, gomock.Any()).
 					Return([]entity.Order{
 						{
 							ID:         "12345678903",
@@ -435,7 +497,7 @@ func Test_service_GetUserOrders(t *testing.T) {
 			name: "should return error if can't get user orders",
 			prepare: func(s *mocks.MockRepository) {
 				s.EXPECT().
-					GetUserOrders(gomock.Any(), 1).
+					GetUserOrders(gomock.Any(), 1, gomock.Any()).
 					Return(nil, errInternal)
 			},
 			want: want{
@@ -459,7 +521,7 @@ func Test_service_GetUserOrders(t *testing.T) {
 				ctx = context.WithValue(context.Background(), k, 1)
 			}
 
-			result, err := service.GetUserOrders(ctx)
+			result, _, err := service.GetUserOrders(ctx, models.ListRequest{Limit: 10})
 
 			if err != nil {
 				assert.Equal(t, tt.want.err, err)
@@ -492,13 +554,19 @@ func Test_service_GetBalance(t *testing.T) {
 					GetBalance(gomock.Any(), 1).
 					Return(&entity.Balance{
 						Current:   13400,
+						Reserved:  500,
 						Withdrawn: 1300,
 					}, nil)
+				s.EXPECT().
+					CountUserOrdersByStatus(gomock.Any(), 1, unfinishedOrderStatuses).
+					Return(2, nil)
 			},
 			want: want{
 				balanceResp: &models.BalanceResponse{
-					Current:   134,
-					Withdrawn: 13,
+					Current:   13400,
+					Reserved:  500,
+					Withdrawn: 1300,
+					Pending:   2,
 				},
 			},
 		},
@@ -520,6 +588,20 @@ func Test_service_GetBalance(t *testing.T) {
 				err: errInternal,
 			},
 		},
+		{
+			name: "should return error if can't get pending orders",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetBalance(gomock.Any(), 1).
+					Return(&entity.Balance{}, nil)
+				s.EXPECT().
+					CountUserOrdersByStatus(gomock.Any(), 1, unfinishedOrderStatuses).
+					Return(0, errInternal)
+			},
+			want: want{
+				err: errInternal,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -548,10 +630,114 @@ func Test_service_GetBalance(t *testing.T) {
 	}
 }
 
+func Test_service_GetBalanceHistory(t *testing.T) {
+	now := time.Now()
+	from := now.AddDate(0, 0, -7)
+
+	service := service{
+		log: logger.NewLogger(),
+	}
+
+	type want struct {
+		history []models.BalanceHistoryResponse
+		err     error
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *mocks.MockRepository)
+		want    want
+	}{
+		{
+			name: "should successfully return balance history",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetBalanceHistory(gomock.Any(), 1, from, time.Time{}).
+					Return([]entity.LedgerEntry{
+						{
+							Kind:         entity.LedgerAccrual,
+							Reference:    "12345678903",
+							Amount:       50000,
+							BalanceAfter: 50000,
+							CreatedAt:    now,
+						},
+						{
+							Kind:         entity.LedgerWithdrawal,
+							Reference:    "2377225624",
+							Amount:       -12050,
+							BalanceAfter: 37950,
+							CreatedAt:    now,
+						},
+					}, nil)
+			},
+			want: want{
+				history: []models.BalanceHistoryResponse{
+					{
+						Type:      entity.LedgerAccrual,
+						Reference: "12345678903",
+						Amount:    50000,
+						Balance:   50000,
+						CreatedAt: now.Format(time.RFC3339),
+					},
+					{
+						Type:      entity.LedgerWithdrawal,
+						Reference: "2377225624",
+						Amount:    -12050,
+						Balance:   37950,
+						CreatedAt: now.Format(time.RFC3339),
+					},
+				},
+			},
+		},
+		{
+			name:    "should return error if can't extract user id from context",
+			prepare: func(s *mocks.MockRepository) {},
+			want: want{
+				err: ErrExtractFromContext,
+			},
+		},
+		{
+			name: "should return error if can't get balance history",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetBalanceHistory(gomock.Any(), 1, from, time.Time{}).
+					Return(nil, errInternal)
+			},
+			want: want{
+				err: errInternal,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			tt.prepare(storage)
+			service.storage = storage
+
+			ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+			if tt.want.err == ErrExtractFromContext {
+				var k badContextKey = "bad_key"
+				ctx = context.WithValue(context.Background(), k, 1)
+			}
+
+			result, err := service.GetBalanceHistory(ctx, from, time.Time{})
+
+			if err != nil {
+				assert.Equal(t, tt.want.err, err)
+			}
+
+			assert.Equal(t, tt.want.history, result)
+		})
+	}
+}
+
 func Test_service_Withdraw(t *testing.T) {
 	service := service{
-		log:                logger.NewLogger(),
-		accrualUpdaterChan: make(chan entity.Order, 20),
+		log: logger.NewLogger(),
 	}
 
 	type want struct {
@@ -568,37 +754,40 @@ func Test_service_Withdraw(t *testing.T) {
 			name: "should successfully accept an order",
 			req: models.WithdrawRequest{
 				Order: "12345678903",
-				Sum:   13,
+				Sum:   1300,
 			},
 			prepare: func(s *mocks.MockRepository) {
-				gomock.InOrder(
-					s.EXPECT().
-						GetBalance(gomock.Any(), 1).
-						Return(&entity.Balance{
-							Current:   13400,
-							Withdrawn: 1300,
-						}, nil),
-					s.EXPECT().
-						Withdraw(gomock.Any(), entity.Withdraw{
-							UserID:  1,
-							OrderID: "12345678903",
-							Sum:     1300,
-						}).
-						Return(nil),
-				)
+				s.EXPECT().
+					Withdraw(gomock.Any(), entity.Withdraw{
+						UserID:  1,
+						OrderID: "12345678903",
+						Sum:     1300,
+					}).
+					Return(nil)
 			},
 		},
 		{
 			name: "should return error if order id doesn't match luhn algorithm",
 			req: models.WithdrawRequest{
 				Order: "123",
-				Sum:   13,
+				Sum:   1300,
 			},
 			prepare: func(s *mocks.MockRepository) {},
 			want: want{
 				err: ErrInvalidOrderID,
 			},
 		},
+		{
+			name: "should return error if withdraw sum is negative",
+			req: models.WithdrawRequest{
+				Order: "12345678903",
+				Sum:   -1300,
+			},
+			prepare: func(s *mocks.MockRepository) {},
+			want: want{
+				err: ErrInvalidWithdrawSum,
+			},
+		},
 		{
 			name:    "should return error if can't extract user id from context",
 			prepare: func(s *mocks.MockRepository) {},
@@ -610,57 +799,54 @@ func Test_service_Withdraw(t *testing.T) {
 			name: "should return error if balance lower than withdraw sum",
 			req: models.WithdrawRequest{
 				Order: "12345678903",
-				Sum:   130,
+				Sum:   13000,
 			},
 			prepare: func(s *mocks.MockRepository) {
 				s.EXPECT().
-					GetBalance(gomock.Any(), 1).
-					Return(&entity.Balance{
-						Current:   1000,
-						Withdrawn: 1300,
-					}, nil)
+					Withdraw(gomock.Any(), entity.Withdraw{
+						UserID:  1,
+						OrderID: "12345678903",
+						Sum:     13000,
+					}).
+					Return(storage.ErrInsufficientFunds)
 			},
 			want: want{
 				err: ErrBalanceNotEnough,
 			},
 		},
 		{
-			name: "should return error if can't get user balance",
+			name: "should return error if order number was already used for a withdrawal",
 			req: models.WithdrawRequest{
 				Order: "12345678903",
-				Sum:   130,
+				Sum:   1300,
 			},
 			prepare: func(s *mocks.MockRepository) {
 				s.EXPECT().
-					GetBalance(gomock.Any(), 1).
-					Return(nil, errInternal)
+					Withdraw(gomock.Any(), entity.Withdraw{
+						UserID:  1,
+						OrderID: "12345678903",
+						Sum:     1300,
+					}).
+					Return(storage.ErrWithdrawalOrderExists)
 			},
 			want: want{
-				err: errInternal,
+				err: ErrWithdrawalOrderUsed,
 			},
 		},
 		{
 			name: "should return error if can't withdraw from balance",
 			req: models.WithdrawRequest{
 				Order: "12345678903",
-				Sum:   13,
+				Sum:   1300,
 			},
 			prepare: func(s *mocks.MockRepository) {
-				gomock.InOrder(
-					s.EXPECT().
-						GetBalance(gomock.Any(), 1).
-						Return(&entity.Balance{
-							Current:   13400,
-							Withdrawn: 1300,
-						}, nil),
-					s.EXPECT().
-						Withdraw(gomock.Any(), entity.Withdraw{
-							UserID:  1,
-							OrderID: "12345678903",
-							Sum:     1300,
-						}).
-						Return(errInternal),
-				)
+				s.EXPECT().
+					Withdraw(gomock.Any(), entity.Withdraw{
+						UserID:  1,
+						OrderID: "12345678903",
+						Sum:     1300,
+					}).
+					Return(errInternal)
 			},
 			want: want{
 				err: errInternal,
@@ -710,21 +896,35 @@ func Test_service_GetUserWithdrawals(t *testing.T) {
 			name: "should successfully return user withdrawals",
 			prepare: func(s *mocks.MockRepository) {
 				s.EXPECT().
-					GetUserWithdrawals(gomock.Any(), 1).
+					GetUserWithdrawals(gomock.Any(), 1, gomock.Any()).
 					Return([]entity.Withdraw{
 						{
 							OrderID:     "12345678903",
 							Sum:         1000,
 							ProcessedAt: now,
 						},
+						{
+							OrderID:     "2377225624",
+							Sum:         500,
+							ProcessedAt: now,
+							ReversedAt:  now,
+						},
 					}, nil)
 			},
 			want: want{
 				withdrawals: []models.WithdrawalsResponse{
 					{
 						Order:       "12345678903",
-						Sum:         10,
+						Sum:         1000,
+						Status:      WithdrawalProcessed,
+						ProcessedAt: now.Format(time.RFC3339),
+					},
+					{
+						Order:       "2377225624",
+						Sum:         500,
+						Status:      WithdrawalReversed,
 						ProcessedAt: now.Format(time.RFC3339),
+						ReversedAt:  now.Format(time.RFC3339),
 					},
 				},
 				err: nil,
@@ -741,7 +941,7 @@ func Test_service_GetUserWithdrawals(t *testing.T) {
 			name: "should return error if can't get user withdrawals",
 			prepare: func(s *mocks.MockRepository) {
 				s.EXPECT().
-					GetUserWithdrawals(gomock.Any(), 1).
+					GetUserWithdrawals(gomock.Any(), 1, gomock.Any()).
 					Return(nil, errInternal)
 			},
 			want: want{
@@ -765,7 +965,7 @@ func Test_service_GetUserWithdrawals(t *testing.T) {
 				ctx = context.WithValue(context.Background(), k, 1)
 			}
 
-			result, err := service.GetUserWithdrawals(ctx)
+			result, _, err := service.GetUserWithdrawals(ctx, models.ListRequest{Limit: 10})
 
 			if err != nil {
 				assert.Equal(t, tt.want.err, err)
@@ -776,6 +976,170 @@ func Test_service_GetUserWithdrawals(t *testing.T) {
 	}
 }
 
+func Test_service_ReverseWithdrawal(t *testing.T) {
+	now := time.Now()
+
+	service := service{
+		log: logger.NewLogger(),
+	}
+
+	type want struct {
+		withdrawal *models.WithdrawalsResponse
+		err        error
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *mocks.MockRepository)
+		want    want
+	}{
+		{
+			name: "should return reversed withdrawal",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					ReverseWithdrawal(gomock.Any(), "12345678903").
+					Return(&entity.Withdraw{
+						UserID:      1,
+						OrderID:     "12345678903",
+						Sum:         1000,
+						ProcessedAt: now,
+						ReversedAt:  now,
+					}, nil)
+			},
+			want: want{
+				withdrawal: &models.WithdrawalsResponse{
+					Order:       "12345678903",
+					Sum:         1000,
+					Status:      WithdrawalReversed,
+					ProcessedAt: now.Format(time.RFC3339),
+					ReversedAt:  now.Format(time.RFC3339),
+				},
+			},
+		},
+		{
+			name: "should return error if withdrawal doesn't exist",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					ReverseWithdrawal(gomock.Any(), "12345678903").
+					Return(nil, storage.ErrWithdrawalNotFound)
+			},
+			want: want{
+				err: ErrWithdrawalNotFound,
+			},
+		},
+		{
+			name: "should return error if can't reverse withdrawal",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					ReverseWithdrawal(gomock.Any(), "12345678903").
+					Return(nil, errInternal)
+			},
+			want: want{
+				err: errInternal,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			tt.prepare(storage)
+			service.storage = storage
+
+			result, err := service.ReverseWithdrawal(context.Background(), "12345678903")
+
+			assert.Equal(t, tt.want.err, err)
+			assert.Equal(t, tt.want.withdrawal, result)
+		})
+	}
+}
+
+func Test_service_GetPendingOrders(t *testing.T) {
+	now := time.Now()
+
+	service := service{
+		log: logger.NewLogger(),
+	}
+
+	type want struct {
+		orders []models.OrderResponse
+		err    error
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(s *mocks.MockRepository)
+		want    want
+	}{
+		{
+			name: "should return orders still being processed",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetUserOrdersByStatus(gomock.Any(), 1, unfinishedOrderStatuses).
+					Return([]entity.Order{
+						{
+							ID:         "12345678903",
+							UserID:     1,
+							Status:     OrderProcessing,
+							UploadedAt: now,
+						},
+					}, nil)
+			},
+			want: want{
+				orders: []models.OrderResponse{
+					{
+						ID:         "12345678903",
+						Status:     OrderProcessing,
+						UploadedAt: now.Format(time.RFC3339),
+					},
+				},
+			},
+		},
+		{
+			name:    "should return error if can't extract user id from context",
+			prepare: func(s *mocks.MockRepository) {},
+			want: want{
+				err: ErrExtractFromContext,
+			},
+		},
+		{
+			name: "should return error if can't get pending orders",
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetUserOrdersByStatus(gomock.Any(), 1, unfinishedOrderStatuses).
+					Return(nil, errInternal)
+			},
+			want: want{
+				err: errInternal,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			tt.prepare(storage)
+			service.storage = storage
+
+			ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+			if tt.want.err == ErrExtractFromContext {
+				var k badContextKey = "bad_key"
+				ctx = context.WithValue(context.Background(), k, 1)
+			}
+
+			result, err := service.GetPendingOrders(ctx)
+
+			assert.Equal(t, tt.want.err, err)
+			assert.Equal(t, tt.want.orders, result)
+		})
+	}
+}
+
 func genHashString(s string) string {
 	hash, _ := bcrypt.GenerateFromPassword([]byte("test_password"), bcrypt.DefaultCost)
 
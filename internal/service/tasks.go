@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// runPeriodically calls task every interval until ctx is done. Errors are
+// logged and the task is retried on the next tick.
+func (s *service) runPeriodically(ctx context.Context, name string, interval time.Duration, task func(ctx context.Context) error) {
+	s.tasksWG.Add(1)
+
+	go func() {
+		defer s.tasksWG.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := task(ctx); err != nil && ctx.Err() == nil {
+				s.log.Error().Err(err).Str("task", name).Msg("periodic task failed")
+			}
+		}
+	}()
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+var ErrInvalidTiers = errors.New("tiers must be a comma separated list of name:threshold:multiplier")
+
+// Tier is a loyalty level a user reaches once their lifetime accrual gets to
+// Threshold, in hundredths of a point.
+type Tier = entity.Tier
+
+// ParseTiers parses tiers written as "BRONZE:0:1,SILVER:1000:1.1", where the
+// threshold is in points. The tiers are returned ordered by threshold, and
+// the lowest one must start at zero so every user has a tier. An empty string
+// disables tiers.
+func ParseTiers(s string) ([]Tier, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var tiers []Tier
+	for _, part := range strings.Split(s, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) != 3 || fields[0] == "" {
+			return nil, ErrInvalidTiers
+		}
+
+		var threshold models.Amount
+		if err := threshold.Set(fields[1]); err != nil || threshold < 0 {
+			return nil, ErrInvalidTiers
+		}
+
+		tier := Tier{Name: strings.ToUpper(fields[0]), Threshold: int(threshold)}
+
+		multiplier, err := decimal.NewFromString(fields[2])
+		if err != nil || multiplier.LessThan(decimal.NewFromInt(1)) {
+			return nil, ErrInvalidTiers
+		}
+		tier.Multiplier = multiplier
+
+		tiers = append(tiers, tier)
+	}
+
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].Threshold < tiers[j].Threshold
+	})
+
+	if tiers[0].Threshold != 0 {
+		return nil, errors.Wrap(ErrInvalidTiers, "lowest tier must start at 0")
+	}
+
+	for i := 1; i < len(tiers); i++ {
+		if tiers[i].Threshold == tiers[i-1].Threshold {
+			return nil, errors.Wrap(ErrInvalidTiers, "tier thresholds must differ")
+		}
+	}
+
+	return tiers, nil
+}
+
+// tierFor returns the highest tier the lifetime accrual reaches.
+func (s *service) tierFor(lifetime int) Tier {
+	return entity.TierFor(s.tiers, lifetime)
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/auth"
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+var testTiers = []Tier{
+	{Name: "BRONZE", Threshold: 0, Multiplier: decimal.NewFromInt(1)},
+	{Name: "SILVER", Threshold: 100000, Multiplier: decimal.RequireFromString("1.1")},
+	{Name: "GOLD", Threshold: 500000, Multiplier: decimal.RequireFromString("1.25")},
+}
+
+func TestParseTiers(t *testing.T) {
+	tests := []struct {
+		name    string
+		tiers   string
+		want    []Tier
+		wantErr error
+	}{
+		{
+			name:  "should parse tiers and order them by threshold",
+			tiers: "gold:5000:1.25, BRONZE:0:1,SILVER:1000:1.1",
+			want:  testTiers,
+		},
+		{
+			name:  "should disable tiers when empty",
+			tiers: "",
+			want:  nil,
+		},
+		{
+			name:    "should reject malformed tier",
+			tiers:   "BRONZE:0",
+			wantErr: ErrInvalidTiers,
+		},
+		{
+			name:    "should reject multiplier below one",
+			tiers:   "BRONZE:0:0.5",
+			wantErr: ErrInvalidTiers,
+		},
+		{
+			name:    "should reject tiers that don't start at zero",
+			tiers:   "SILVER:1000:1.1",
+			wantErr: ErrInvalidTiers,
+		},
+		{
+			name:    "should reject tiers with the same threshold",
+			tiers:   "BRONZE:0:1,SILVER:0:1.1",
+			wantErr: ErrInvalidTiers,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiers, err := ParseTiers(tt.tiers)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, tiers, len(tt.want))
+			for i := range tt.want {
+				assert.Equal(t, tt.want[i].Name, tiers[i].Name)
+				assert.Equal(t, tt.want[i].Threshold, tiers[i].Threshold)
+				assert.True(t, tt.want[i].Multiplier.Equal(tiers[i].Multiplier))
+			}
+		})
+	}
+}
+
+func Test_service_orderCredit(t *testing.T) {
+	order := entity.Order{ID: "12345678903", UserID: 1, Accrual: 50005, Status: OrderProcessed}
+
+	ctrl := gomock.NewController(t)
+	storage := mocks.NewMockRepository(ctrl)
+
+	storage.EXPECT().
+		GetActiveCampaigns(gomock.Any()).
+		Return(nil, nil)
+
+	service := service{
+		log:           logger.NewLogger(),
+		storage:       storage,
+		tiers:         testTiers,
+		referralBonus: 1000,
+		referralLimit: 5,
+	}
+
+	credit, err := service.orderCredit(context.Background(), order)
+
+	require.NoError(t, err)
+	assert.Equal(t, entity.OrderCredit{
+		Tiers:    testTiers,
+		Referral: entity.ReferralCredit{Bonus: 1000, Limit: 5},
+	}, credit)
+}
+
+func Test_service_GetBalance_tier(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage := mocks.NewMockRepository(ctrl)
+
+	storage.EXPECT().
+		GetBalance(gomock.Any(), 1).
+		Return(&entity.Balance{UserID: 1, Current: 1000}, nil)
+	storage.EXPECT().
+		CountUserOrdersByStatus(gomock.Any(), 1, unfinishedOrderStatuses).
+		Return(0, nil)
+	storage.EXPECT().
+		GetLifetimeAccrual(gomock.Any(), 1).
+		Return(500000, nil)
+
+	service := service{
+		log:     logger.NewLogger(),
+		storage: storage,
+		tiers:   testTiers,
+	}
+
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+	result, err := service.GetBalance(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &models.BalanceResponse{Current: 1000, Tier: "GOLD"}, result)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+// Transfer moves points from the current user to the user with the given
+// login, within the configured transfer limits.
+func (s *service) Transfer(ctx context.Context, req models.TransferRequest) error {
+	userID, err := extractUserIDFromCtx(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to extracrt user from context")
+		return err
+	}
+
+	if req.Sum <= 0 {
+		s.log.Error().Str("sum", req.Sum.String()).Int("user", userID).Msg(ErrInvalidWithdrawSum.Error())
+		return ErrInvalidWithdrawSum
+	}
+
+	if s.transferLimit > 0 && req.Sum > s.transferLimit {
+		s.log.Info().Str("sum", req.Sum.String()).Int("user", userID).Msg(ErrTransferLimitExceeded.Error())
+		return ErrTransferLimitExceeded
+	}
+
+	recipient, err := s.storage.GetUser(ctx, req.Login)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.log.Info().Str("login", req.Login).Int("user", userID).Msg(ErrRecipientNotFound.Error())
+		return ErrRecipientNotFound
+	}
+
+	if err != nil {
+		s.log.Error().Err(err).Str("login", req.Login).Msg("cannot find user in database")
+		return err
+	}
+
+	if recipient.ID == userID {
+		s.log.Info().Int("user", userID).Msg(ErrTransferToSelf.Error())
+		return ErrTransferToSelf
+	}
+
+	transfer := entity.Transfer{
+		FromUserID: userID,
+		ToUserID:   recipient.ID,
+		Sum:        int(req.Sum),
+	}
+
+	err = s.storage.Transfer(ctx, transfer, int(s.transferDailyLimit))
+	if errors.Is(err, storage.ErrInsufficientFunds) {
+		s.log.Info().Int("user", userID).Int("sum", transfer.Sum).Msg(ErrBalanceNotEnough.Error())
+		return ErrBalanceNotEnough
+	}
+
+	if errors.Is(err, storage.ErrTransferLimitExceeded) {
+		s.log.Info().Int("user", userID).Int("sum", transfer.Sum).Msg(ErrTransferLimitExceeded.Error())
+		return ErrTransferLimitExceeded
+	}
+
+	if err != nil {
+		s.log.Error().Err(err).Int("user", userID).Msg("failed to transfer points")
+		return err
+	}
+
+	s.log.Info().Int("user", userID).Int("recipient", recipient.ID).Int("sum", transfer.Sum).Msg("points were transferred")
+
+	return nil
+}
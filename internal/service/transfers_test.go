@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrahaTurbo/gophermart/internal/auth"
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/mocks"
+	"github.com/PrahaTurbo/gophermart/internal/models"
+	"github.com/PrahaTurbo/gophermart/internal/storage"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func Test_service_Transfer(t *testing.T) {
+	service := service{
+		log:                logger.NewLogger(),
+		transferLimit:      10000,
+		transferDailyLimit: 50000,
+	}
+
+	type want struct {
+		err error
+	}
+
+	tests := []struct {
+		name    string
+		req     models.TransferRequest
+		prepare func(s *mocks.MockRepository)
+		want    want
+	}{
+		{
+			name: "should transfer points",
+			req: models.TransferRequest{
+				Login: "family",
+				Sum:   1300,
+			},
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetUser(gomock.Any(), "family").
+					Return(&entity.User{ID: 2, Login: "family"}, nil)
+				s.EXPECT().
+					Transfer(gomock.Any(), entity.Transfer{
+						FromUserID: 1,
+						ToUserID:   2,
+						Sum:        1300,
+					}, 50000).
+					Return(nil)
+			},
+		},
+		{
+			name: "should return error if sum is negative",
+			req: models.TransferRequest{
+				Login: "family",
+				Sum:   -1300,
+			},
+			prepare: func(s *mocks.MockRepository) {},
+			want: want{
+				err: ErrInvalidWithdrawSum,
+			},
+		},
+		{
+			name: "should return error if sum is above transfer limit",
+			req: models.TransferRequest{
+				Login: "family",
+				Sum:   10001,
+			},
+			prepare: func(s *mocks.MockRepository) {},
+			want: want{
+				err: ErrTransferLimitExceeded,
+			},
+		},
+		{
+			name: "should return error if recipient doesn't exist",
+			req: models.TransferRequest{
+				Login: "nobody",
+				Sum:   1300,
+			},
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetUser(gomock.Any(), "nobody").
+					Return(nil, sql.ErrNoRows)
+			},
+			want: want{
+				err: ErrRecipientNotFound,
+			},
+		},
+		{
+			name: "should return error if recipient is current user",
+			req: models.TransferRequest{
+				Login: "me",
+				Sum:   1300,
+			},
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetUser(gomock.Any(), "me").
+					Return(&entity.User{ID: 1, Login: "me"}, nil)
+			},
+			want: want{
+				err: ErrTransferToSelf,
+			},
+		},
+		{
+			name: "should return error if balance lower than transfer sum",
+			req: models.TransferRequest{
+				Login: "family",
+				Sum:   1300,
+			},
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetUser(gomock.Any(), "family").
+					Return(&entity.User{ID: 2, Login: "family"}, nil)
+				s.EXPECT().
+					Transfer(gomock.Any(), gomock.Any(), 50000).
+					Return(storage.ErrInsufficientFunds)
+			},
+			want: want{
+				err: ErrBalanceNotEnough,
+			},
+		},
+		{
+			name: "should return error if daily limit is exceeded",
+			req: models.TransferRequest{
+				Login: "family",
+				Sum:   1300,
+			},
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetUser(gomock.Any(), "family").
+					Return(&entity.User{ID: 2, Login: "family"}, nil)
+				s.EXPECT().
+					Transfer(gomock.Any(), gomock.Any(), 50000).
+					Return(storage.ErrTransferLimitExceeded)
+			},
+			want: want{
+				err: ErrTransferLimitExceeded,
+			},
+		},
+		{
+			name: "should return error if can't transfer points",
+			req: models.TransferRequest{
+				Login: "family",
+				Sum:   1300,
+			},
+			prepare: func(s *mocks.MockRepository) {
+				s.EXPECT().
+					GetUser(gomock.Any(), "family").
+					Return(&entity.User{ID: 2, Login: "family"}, nil)
+				s.EXPECT().
+					Transfer(gomock.Any(), gomock.Any(), 50000).
+					Return(errInternal)
+			},
+			want: want{
+				err: errInternal,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			storage := mocks.NewMockRepository(ctrl)
+
+			tt.prepare(storage)
+			service.storage = storage
+
+			ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+			err := service.Transfer(ctx, tt.req)
+			assert.Equal(t, tt.want.err, err)
+		})
+	}
+}
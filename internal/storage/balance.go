@@ -24,39 +24,32 @@ func (s *Storage) CreateBalance(ctx context.Context, userID int) error {
 	return nil
 }
 
+// GetBalance derives the balance from the user's ledger entries. Held points
+// are not part of the current balance until the hold is released.
 func (s *Storage) GetBalance(ctx context.Context, userID int) (*entity.Balance, error) {
 	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
 	defer cancel()
 
 	query := `
-		SELECT current, withdrawn
-		FROM balances
-		WHERE user_id = $1`
+		SELECT COALESCE(SUM(e.amount) FILTER (WHERE e.account = $2), 0), 
+		       COALESCE(SUM(e.amount) FILTER (WHERE e.account = $3), 0), 
+		       COALESCE(-SUM(e.amount) FILTER (WHERE t.kind = ANY($4)), 0)
+		FROM ledger_entries e
+		JOIN ledger_transactions t ON t.id = e.transaction_id
+		WHERE e.user_id = $1`
+
+	kinds := make([]string, 0, len(withdrawnKinds))
+	for kind := range withdrawnKinds {
+		kinds = append(kinds, kind)
+	}
 
-	row := s.db.QueryRowContext(timeoutCtx, query, userID)
+	row := s.db.QueryRowContext(timeoutCtx, query, userID, entity.AccountUser, entity.AccountReserved, kinds)
 
-	var balance entity.Balance
-	err := row.Scan(&balance.Current, &balance.Withdrawn)
+	balance := entity.Balance{UserID: userID}
+	err := row.Scan(&balance.Current, &balance.Reserved, &balance.Withdrawn)
 	if err != nil {
 		return nil, err
 	}
 
 	return &balance, nil
 }
-
-func (s *Storage) UpdateBalance(amount int, userID int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*contextTimeoutSeconds)
-	defer cancel()
-
-	query := `
-		UPDATE balances 
-		SET current = current + $1
-		WHERE user_id = $2`
-
-	_, err := s.db.ExecContext(ctx, query, amount, userID)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func (s *Storage) SaveCampaign(ctx context.Context, c entity.Campaign) (*entity.Campaign, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		INSERT INTO campaigns 
+		    (name, 
+		     multiplier, 
+		     bonus, 
+		     min_accrual, 
+		     first_order_only, 
+		     starts_at, 
+		     ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	err := s.db.QueryRowContext(timeoutCtx, query,
+		c.Name, c.Multiplier, c.Bonus, c.MinAccrual, c.FirstOrderOnly, c.StartsAt, c.EndsAt).
+		Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// GetCampaigns returns all campaigns, the latest to start first.
+func (s *Storage) GetCampaigns(ctx context.Context) ([]entity.Campaign, error) {
+	query := `
+		SELECT id, 
+		       name, 
+		       multiplier, 
+		       bonus, 
+		       min_accrual, 
+		       first_order_only, 
+		       starts_at, 
+		       ends_at, 
+		       created_at
+		FROM campaigns
+		ORDER BY starts_at DESC, id DESC`
+
+	return s.queryCampaigns(ctx, query)
+}
+
+// GetActiveCampaigns returns the campaigns running now by the database clock.
+// Campaign periods are kept in UTC.
+func (s *Storage) GetActiveCampaigns(ctx context.Context) ([]entity.Campaign, error) {
+	query := `
+		SELECT id, 
+		       name, 
+		       multiplier, 
+		       bonus, 
+		       min_accrual, 
+		       first_order_only, 
+		       starts_at, 
+		       ends_at, 
+		       created_at
+		FROM campaigns
+		WHERE starts_at <= CURRENT_TIMESTAMP AT TIME ZONE 'UTC'
+		  AND ends_at > CURRENT_TIMESTAMP AT TIME ZONE 'UTC'
+		ORDER BY id`
+
+	return s.queryCampaigns(ctx, query)
+}
+
+// CountCreditedOrders returns the number of the user's orders whose accrual
+// was credited.
+func (s *Storage) CountCreditedOrders(ctx context.Context, userID int) (int, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		SELECT COUNT(*)
+		FROM orders
+		WHERE user_id = $1
+		  AND credited_at IS NOT NULL`
+
+	var count int
+	if err := s.db.QueryRowContext(timeoutCtx, query, userID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (s *Storage) queryCampaigns(ctx context.Context, query string, args ...any) ([]entity.Campaign, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(timeoutCtx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var campaigns []entity.Campaign
+	for rows.Next() {
+		var c entity.Campaign
+
+		err := rows.Scan(
+			&c.ID,
+			&c.Name,
+			&c.Multiplier,
+			&c.Bonus,
+			&c.MinAccrual,
+			&c.FirstOrderOnly,
+			&c.StartsAt,
+			&c.EndsAt,
+			&c.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		campaigns = append(campaigns, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+// applyCampaignBonuses posts each campaign bonus of the order as a ledger
+// transaction of its own within tx. A first order campaign is keyed by user
+// rather than by order, so the ledger pays it out once per user even when
+// two first orders are credited at the same time.
+func (s *Storage) applyCampaignBonuses(ctx context.Context, tx *sql.Tx, userID int, order entity.Order, bonuses []entity.CampaignBonus) error {
+	for _, b := range bonuses {
+		if b.Bonus <= 0 {
+			continue
+		}
+
+		sourceID := fmt.Sprintf("%d:order:%s", b.CampaignID, order.ID)
+		if b.FirstOrderOnly {
+			sourceID = fmt.Sprintf("%d:user:%d", b.CampaignID, userID)
+		}
+
+		lt := ledgerTransaction{
+			kind:      entity.LedgerCampaignBonus,
+			sourceID:  sourceID,
+			reference: order.ID,
+		}
+
+		_, err := s.postLedgerTransaction(ctx, tx, lt,
+			userPosting(userID, b.Bonus),
+			systemPosting(entity.AccountCampaigns, -b.Bonus),
+		)
+		if err != nil && !errors.Is(err, ErrDuplicateLedgerTransaction) {
+			return err
+		}
+	}
+
+	return nil
+}
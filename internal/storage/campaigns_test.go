@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func TestStorage_CreditOrderAccrual_CampaignBonuses(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	credit := entity.OrderCredit{
+		CampaignBonuses: []entity.CampaignBonus{
+			{CampaignID: 1, Bonus: 500},
+			{CampaignID: 2, Bonus: 10000, FirstOrderOnly: true},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		order := entity.Order{
+			ID:     fmt.Sprintf("%d", time.Now().UnixNano()),
+			UserID: userID,
+			Status: "NEW",
+		}
+		require.NoError(t, s.SaveOrder(ctx, order))
+
+		order.Status = "PROCESSED"
+		order.Accrual = 1000
+
+		require.NoError(t, s.CreditOrderAccrual(ctx, order, credit))
+	}
+
+	balance, err := s.GetBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2*1000+2*500+10000, balance.Current)
+
+	history, err := s.GetBalanceHistory(ctx, userID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+
+	var bonuses int
+	for _, e := range history {
+		if e.Kind == entity.LedgerCampaignBonus {
+			bonuses++
+		}
+	}
+	assert.Equal(t, 3, bonuses)
+}
+
+func TestStorage_GetActiveCampaigns(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+
+	active, err := s.SaveCampaign(ctx, entity.Campaign{
+		Name:       "running",
+		Multiplier: decimal.NewFromInt(2),
+		StartsAt:   now.Add(-time.Hour),
+		EndsAt:     now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	_, err = s.SaveCampaign(ctx, entity.Campaign{
+		Name:       "over",
+		Multiplier: decimal.NewFromInt(2),
+		StartsAt:   now.Add(-time.Hour * 2),
+		EndsAt:     now.Add(-time.Hour),
+	})
+	require.NoError(t, err)
+
+	campaigns, err := s.GetActiveCampaigns(ctx)
+	require.NoError(t, err)
+
+	var ids []int
+	for _, c := range campaigns {
+		ids = append(ids, c.ID)
+		assert.NotEqual(t, "over", c.Name)
+	}
+	assert.Contains(t, ids, active.ID)
+}
@@ -1,30 +1,281 @@
 package entity
 
-import "time"
+import (
+	"time"
 
+	"github.com/shopspring/decimal"
+)
+
+// User is a registered user. ReferrerID is the user whose referral code was
+// given at registration, zero if none was.
 type User struct {
 	ID           int
 	Login        string
 	PasswordHash string
+	ReferralCode string
+	ReferrerID   int
 }
 
 type Balance struct {
 	UserID    int
 	Current   int
+	Reserved  int
 	Withdrawn int
 }
 
+// Order is an uploaded order number. CreditedAt is zero until its accrual is
+// credited to the user.
 type Order struct {
 	ID         string
 	UserID     int
 	Accrual    int
 	Status     string
 	UploadedAt time.Time
+	CreditedAt time.Time
 }
 
+// Withdraw is a payment with points. ReversedAt is zero unless the points
+// were returned to the user.
 type Withdraw struct {
+	ID          int
 	UserID      int
 	OrderID     string
 	Sum         int
 	ProcessedAt time.Time
+	ReversedAt  time.Time
+}
+
+// Page selects a page of a list sorted by time and then id. After is the
+// last row of the previous page, nil for the first page. Zero Limit selects
+// all the rows. Zero From or To leaves that side of the time range open.
+type Page struct {
+	After *Cursor
+	Limit int
+	From  time.Time
+	To    time.Time
+	Desc  bool
+}
+
+// Cursor is the position of a row in a list sorted by time and id.
+type Cursor struct {
+	At time.Time
+	ID string
+}
+
+// OrderFilter selects the user's orders in one of Statuses, or in any status
+// if none is given.
+type OrderFilter struct {
+	Page
+	Statuses []string
+}
+
+// WithdrawalFilter selects the user's withdrawals that were, or were not,
+// reversed. Nil Reversed selects both.
+type WithdrawalFilter struct {
+	Page
+	Reversed *bool
+}
+
+// OrderUpload is the outcome of uploading an order number: the owner of the
+// order, zero if it is not known, and whether this upload created it.
+type OrderUpload struct {
+	OrderID string
+	OwnerID int
+	Created bool
+}
+
+// OrderCredit is what crediting an order brings on top of its accrual: the
+// bonus of the user's tier, the bonuses of the campaigns the order qualifies
+// for and the referral bonus. The tier bonus and the tier change depend on
+// the user's lifetime accrual at the time of crediting, so they are up to the
+// storage; no Tiers means tiers are off.
+type OrderCredit struct {
+	Tiers           []Tier
+	CampaignBonuses []CampaignBonus
+	Referral        ReferralCredit
+}
+
+// Tier is a loyalty level a user reaches once their lifetime accrual gets to
+// Threshold. Accruals of the tier's members are multiplied by Multiplier.
+type Tier struct {
+	Name       string
+	Threshold  int
+	Multiplier decimal.Decimal
+}
+
+// TierFor returns the highest of tiers, ordered by threshold, that the
+// lifetime accrual reaches.
+func TierFor(tiers []Tier, lifetime int) Tier {
+	tier := tiers[0]
+	for _, t := range tiers[1:] {
+		if lifetime >= t.Threshold {
+			tier = t
+		}
+	}
+
+	return tier
+}
+
+// ReferralCredit is the bonus both the user and their referrer get once the
+// user's first order is processed. A referrer is rewarded for at most Limit
+// referrals, zero means no limit.
+type ReferralCredit struct {
+	Bonus int
+	Limit int
+}
+
+const (
+	ReferralPending  = "PENDING"
+	ReferralRewarded = "REWARDED"
+	ReferralCapped   = "CAPPED"
+)
+
+// ReferralStats sums up the referrals of a user.
+type ReferralStats struct {
+	Code     string
+	Referred int
+	Rewarded int
+}
+
+// Campaign is a time-boxed promotion that adds a bonus to the accrual of
+// orders credited between StartsAt and EndsAt. The bonus is the accrual times
+// Multiplier minus one, plus Bonus. Only orders with at least MinAccrual
+// qualify, and only a user's first order if FirstOrderOnly is set.
+type Campaign struct {
+	ID             int
+	Name           string
+	Multiplier     decimal.Decimal
+	Bonus          int
+	MinAccrual     int
+	FirstOrderOnly bool
+	StartsAt       time.Time
+	EndsAt         time.Time
+	CreatedAt      time.Time
+}
+
+// CampaignBonus is the bonus an order earns in a campaign. A first order
+// campaign pays out once per user.
+type CampaignBonus struct {
+	CampaignID     int
+	Bonus          int
+	FirstOrderOnly bool
+}
+
+// Reward is an item of the rewards catalog. Only active rewards in stock can
+// be redeemed.
+type Reward struct {
+	ID          int
+	Name        string
+	Description string
+	Price       int
+	Stock       int
+	Active      bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Redemption is a reward a user paid for with points. Price is what the
+// reward cost at the time.
+type Redemption struct {
+	ID         int
+	UserID     int
+	RewardID   int
+	RewardName string
+	Price      int
+	RedeemedAt time.Time
+}
+
+type Transfer struct {
+	FromUserID int
+	ToUserID   int
+	Sum        int
+}
+
+// ExpiringPoints are unspent points of a user earned longer than some time
+// ago. EarnedAt is when the oldest of them was earned.
+type ExpiringPoints struct {
+	UserID   int
+	Amount   int
+	EarnedAt time.Time
+}
+
+// Hold is a number of points reserved for an order until it is captured,
+// released or expires.
+type Hold struct {
+	ID         int
+	UserID     int
+	OrderID    string
+	Sum        int
+	Status     string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	ResolvedAt time.Time
+}
+
+// Hold statuses.
+const (
+	HoldHeld     = "HELD"
+	HoldCaptured = "CAPTURED"
+	HoldReleased = "RELEASED"
+	HoldExpired  = "EXPIRED"
+)
+
+type AccrualJob struct {
+	OrderID  string
+	UserID   int
+	Attempts int
+	Age      time.Duration
+}
+
+// Ledger accounts. Points of every user live on their own AccountUser, held
+// points are moved to their own AccountReserved; the other accounts are
+// system-wide counterparts the points come from or go to.
+const (
+	AccountUser        = "USER"
+	AccountReserved    = "RESERVED"
+	AccountAccruals    = "ACCRUALS"
+	AccountWithdrawals = "WITHDRAWALS"
+	AccountAdjustments = "ADJUSTMENTS"
+	AccountExpired     = "EXPIRED"
+	AccountBonuses     = "BONUSES"
+	AccountCampaigns   = "CAMPAIGNS"
+	AccountReferrals   = "REFERRALS"
+	AccountRewards     = "REWARDS"
+)
+
+// Ledger transaction kinds.
+const (
+	LedgerAccrual       = "ACCRUAL"
+	LedgerWithdrawal    = "WITHDRAWAL"
+	LedgerAdjustment    = "ADJUSTMENT"
+	LedgerReversal      = "REVERSAL"
+	LedgerHold          = "HOLD"
+	LedgerCapture       = "CAPTURE"
+	LedgerRelease       = "RELEASE"
+	LedgerExpiry        = "EXPIRY"
+	LedgerTransfer      = "TRANSFER"
+	LedgerTierBonus     = "TIER_BONUS"
+	LedgerCampaignBonus = "CAMPAIGN_BONUS"
+	LedgerReferralBonus = "REFERRAL_BONUS"
+	LedgerRedemption    = "REDEMPTION"
+)
+
+type LedgerEntry struct {
+	ID           int64
+	Kind         string
+	Reference    string
+	Amount       int
+	BalanceAfter int
+	CreatedAt    time.Time
+}
+
+// IdempotencyRecord is the stored outcome of a request sent with an
+// idempotency key. StatusCode is zero while the first request is running.
+type IdempotencyRecord struct {
+	UserID       int
+	Key          string
+	RequestHash  string
+	StatusCode   int
+	ContentType  string
+	ResponseBody []byte
 }
@@ -0,0 +1,338 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+var (
+	ErrHoldNotFound  = errors.New("hold not found")
+	ErrHoldNotActive = errors.New("hold was already captured, released or has expired")
+)
+
+// HoldPoints moves the sum of the hold from the user's current balance to the
+// reserved one until the hold is captured, released or expires after ttl. An
+// order number used by an active or captured hold or by a withdrawal returns
+// ErrWithdrawalOrderExists.
+func (s *Storage) HoldPoints(ctx context.Context, h entity.Hold, ttl time.Duration) (*entity.Hold, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := useOrderNumber(timeoutCtx, tx, h.UserID, h.OrderID); err != nil {
+		return nil, err
+	}
+
+	holdQuery := `
+		INSERT INTO holds 
+		    (user_id, 
+		     order_id, 
+		     sum, 
+		     status, 
+		     expires_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP + ($5 * INTERVAL '1 millisecond'))
+		RETURNING id, created_at, expires_at`
+
+	h.Status = entity.HoldHeld
+
+	err = tx.QueryRowContext(timeoutCtx, holdQuery, h.UserID, h.OrderID, h.Sum, h.Status, ttl.Milliseconds()).
+		Scan(&h.ID, &h.CreatedAt, &h.ExpiresAt)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok {
+			if pgErr.Code == uniqueViolationErrCode {
+				return nil, ErrWithdrawalOrderExists
+			}
+		}
+		return nil, err
+	}
+
+	lt := ledgerTransaction{
+		kind:      entity.LedgerHold,
+		sourceID:  strconv.Itoa(h.ID),
+		reference: h.OrderID,
+	}
+
+	_, err = s.postLedgerTransaction(timeoutCtx, tx, lt,
+		userPosting(h.UserID, -h.Sum),
+		reservedPosting(h.UserID, h.Sum),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+// CaptureHold turns the user's hold for the order into a withdrawal of the
+// held points. Capturing a captured hold returns it unchanged.
+func (s *Storage) CaptureHold(ctx context.Context, userID int, orderID string) (*entity.Hold, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	h, expired, err := lockHold(timeoutCtx, tx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.Status == entity.HoldCaptured {
+		return h, nil
+	}
+
+	if h.Status != entity.HoldHeld || expired {
+		return nil, ErrHoldNotActive
+	}
+
+	withdrawQuery := `
+		INSERT INTO withdrawals 
+		    (user_id, 
+		     order_id, 
+		     sum)
+		VALUES ($1, $2, $3)`
+
+	_, err = tx.ExecContext(timeoutCtx, withdrawQuery, h.UserID, h.OrderID, h.Sum)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok {
+			if pgErr.Code == uniqueViolationErrCode {
+				return nil, ErrWithdrawalOrderExists
+			}
+		}
+		return nil, err
+	}
+
+	if err := resolveHold(timeoutCtx, tx, h, entity.HoldCaptured); err != nil {
+		return nil, err
+	}
+
+	lt := ledgerTransaction{
+		kind:      entity.LedgerCapture,
+		sourceID:  strconv.Itoa(h.ID),
+		reference: h.OrderID,
+	}
+
+	_, err = s.postLedgerTransaction(timeoutCtx, tx, lt,
+		reservedPosting(h.UserID, -h.Sum),
+		systemPosting(entity.AccountWithdrawals, h.Sum),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// ReleaseHold returns the points of the user's hold for the order to the
+// current balance. Releasing a released or expired hold returns it unchanged.
+func (s *Storage) ReleaseHold(ctx context.Context, userID int, orderID string) (*entity.Hold, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	h, _, err := lockHold(timeoutCtx, tx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.Status == entity.HoldReleased || h.Status == entity.HoldExpired {
+		return h, nil
+	}
+
+	if h.Status != entity.HoldHeld {
+		return nil, ErrHoldNotActive
+	}
+
+	if err := s.releaseHold(timeoutCtx, tx, h, entity.HoldReleased); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// ExpireHolds releases up to limit holds that were neither captured nor
+// released in time and returns how many it released.
+func (s *Storage) ExpireHolds(ctx context.Context, limit int) (int, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, 
+		       user_id, 
+		       order_id, 
+		       sum, 
+		       status, 
+		       created_at, 
+		       expires_at
+		FROM holds
+		WHERE status = $1
+		  AND expires_at <= CURRENT_TIMESTAMP
+		ORDER BY expires_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.QueryContext(timeoutCtx, query, entity.HoldHeld, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var holds []*entity.Hold
+	for rows.Next() {
+		var h entity.Hold
+
+		err := rows.Scan(&h.ID, &h.UserID, &h.OrderID, &h.Sum, &h.Status, &h.CreatedAt, &h.ExpiresAt)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		holds = append(holds, &h)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, h := range holds {
+		if err := s.releaseHold(timeoutCtx, tx, h, entity.HoldExpired); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(holds), nil
+}
+
+// lockHold loads the user's latest hold for the order and locks it until tx
+// ends. expired reports whether the hold is past its expiry time.
+func lockHold(ctx context.Context, tx *sql.Tx, userID int, orderID string) (*entity.Hold, bool, error) {
+	query := `
+		SELECT id, 
+		       sum, 
+		       status, 
+		       created_at, 
+		       expires_at, 
+		       resolved_at, 
+		       expires_at <= CURRENT_TIMESTAMP
+		FROM holds
+		WHERE user_id = $1
+		  AND order_id = $2
+		ORDER BY id DESC
+		LIMIT 1
+		FOR UPDATE`
+
+	h := entity.Hold{
+		UserID:  userID,
+		OrderID: orderID,
+	}
+
+	var (
+		resolvedAt sql.NullTime
+		expired    bool
+	)
+
+	err := tx.QueryRowContext(ctx, query, userID, orderID).
+		Scan(&h.ID, &h.Sum, &h.Status, &h.CreatedAt, &h.ExpiresAt, &resolvedAt, &expired)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, ErrHoldNotFound
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	h.ResolvedAt = resolvedAt.Time
+
+	return &h, expired, nil
+}
+
+// releaseHold moves the held points back to the user's current balance,
+// resolves the hold with status and frees its order number, so the order can
+// be paid for again.
+func (s *Storage) releaseHold(ctx context.Context, tx *sql.Tx, h *entity.Hold, status string) error {
+	if err := resolveHold(ctx, tx, h, status); err != nil {
+		return err
+	}
+
+	if err := freeOrderNumber(ctx, tx, h.OrderID); err != nil {
+		return err
+	}
+
+	lt := ledgerTransaction{
+		kind:      entity.LedgerRelease,
+		sourceID:  strconv.Itoa(h.ID),
+		reference: h.OrderID,
+	}
+
+	hold := ledgerTransaction{
+		kind:     entity.LedgerHold,
+		sourceID: strconv.Itoa(h.ID),
+	}
+
+	_, err := s.postLedgerTransaction(ctx, tx, lt,
+		reservedPosting(h.UserID, -h.Sum),
+		restoringPosting(h.UserID, h.Sum, hold),
+	)
+
+	return err
+}
+
+func resolveHold(ctx context.Context, tx *sql.Tx, h *entity.Hold, status string) error {
+	query := `
+		UPDATE holds
+		SET status = $1,
+		    resolved_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING resolved_at`
+
+	if err := tx.QueryRowContext(ctx, query, status, h.ID).Scan(&h.ResolvedAt); err != nil {
+		return err
+	}
+
+	h.Status = status
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func TestStorage_Holds(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	creditTestUser(t, s, userID, 1000)
+
+	captured := fmt.Sprintf("%d-captured", userID)
+	released := fmt.Sprintf("%d-released", userID)
+
+	_, err := s.HoldPoints(ctx, entity.Hold{UserID: userID, OrderID: captured, Sum: 300}, time.Minute)
+	require.NoError(t, err)
+
+	_, err = s.HoldPoints(ctx, entity.Hold{UserID: userID, OrderID: released, Sum: 200}, time.Minute)
+	require.NoError(t, err)
+
+	_, err = s.HoldPoints(ctx, entity.Hold{UserID: userID, OrderID: fmt.Sprintf("%d-big", userID), Sum: 600}, time.Minute)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = s.HoldPoints(ctx, entity.Hold{UserID: userID, OrderID: captured, Sum: 100}, time.Minute)
+	assert.ErrorIs(t, err, ErrWithdrawalOrderExists)
+
+	err = s.Withdraw(ctx, entity.Withdraw{UserID: userID, OrderID: released, Sum: 100})
+	assert.ErrorIs(t, err, ErrWithdrawalOrderExists)
+
+	balance, err := s.GetBalance(ctx, userID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 500, balance.Current)
+	assert.Equal(t, 500, balance.Reserved)
+	assert.Equal(t, 0, balance.Withdrawn)
+
+	for i := 0; i < 2; i++ {
+		h, err := s.CaptureHold(ctx, userID, captured)
+		require.NoError(t, err)
+		assert.Equal(t, entity.HoldCaptured, h.Status)
+
+		h, err = s.ReleaseHold(ctx, userID, released)
+		require.NoError(t, err)
+		assert.Equal(t, entity.HoldReleased, h.Status)
+	}
+
+	_, err = s.ReleaseHold(ctx, userID, captured)
+	assert.ErrorIs(t, err, ErrHoldNotActive)
+
+	_, err = s.CaptureHold(ctx, userID, released)
+	assert.ErrorIs(t, err, ErrHoldNotActive)
+
+	_, err = s.CaptureHold(ctx, userID+1, captured)
+	assert.ErrorIs(t, err, ErrHoldNotFound)
+
+	balance, err = s.GetBalance(ctx, userID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 700, balance.Current)
+	assert.Equal(t, 0, balance.Reserved)
+	assert.Equal(t, 300, balance.Withdrawn)
+
+	withdrawals, err := s.GetUserWithdrawals(ctx, userID, entity.WithdrawalFilter{Page: entity.Page{Limit: 100}})
+	require.NoError(t, err)
+	require.Len(t, withdrawals, 1)
+	assert.Equal(t, captured, withdrawals[0].OrderID)
+}
+
+func TestStorage_ExpireHolds(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	creditTestUser(t, s, userID, 1000)
+
+	orderID := fmt.Sprintf("%d-expired", userID)
+
+	_, err := s.HoldPoints(ctx, entity.Hold{UserID: userID, OrderID: orderID, Sum: 300}, -time.Second)
+	require.NoError(t, err)
+
+	_, err = s.CaptureHold(ctx, userID, orderID)
+	assert.ErrorIs(t, err, ErrHoldNotActive)
+
+	expired, err := s.ExpireHolds(ctx, 1000)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, expired, 1)
+
+	h, err := s.ReleaseHold(ctx, userID, orderID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.HoldExpired, h.Status)
+
+	balance, err := s.GetBalance(ctx, userID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1000, balance.Current)
+	assert.Equal(t, 0, balance.Reserved)
+}
+
+func TestStorage_Holds_FreeOrderNumber(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	creditTestUser(t, s, userID, 1000)
+
+	retried := fmt.Sprintf("%d-retried", userID)
+	expired := fmt.Sprintf("%d-expired", userID)
+
+	_, err := s.HoldPoints(ctx, entity.Hold{UserID: userID, OrderID: retried, Sum: 300}, time.Minute)
+	require.NoError(t, err)
+
+	_, err = s.ReleaseHold(ctx, userID, retried)
+	require.NoError(t, err)
+
+	// A checkout retried after a cancel holds the points again.
+	_, err = s.HoldPoints(ctx, entity.Hold{UserID: userID, OrderID: retried, Sum: 300}, time.Minute)
+	require.NoError(t, err)
+
+	h, err := s.CaptureHold(ctx, userID, retried)
+	require.NoError(t, err)
+	assert.Equal(t, entity.HoldCaptured, h.Status)
+
+	_, err = s.HoldPoints(ctx, entity.Hold{UserID: userID, OrderID: retried, Sum: 100}, time.Minute)
+	assert.ErrorIs(t, err, ErrWithdrawalOrderExists)
+
+	_, err = s.HoldPoints(ctx, entity.Hold{UserID: userID, OrderID: expired, Sum: 200}, -time.Second)
+	require.NoError(t, err)
+
+	_, err = s.ExpireHolds(ctx, 1000)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Withdraw(ctx, entity.Withdraw{UserID: userID, OrderID: expired, Sum: 200}))
+
+	balance, err := s.GetBalance(ctx, userID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 500, balance.Current)
+	assert.Equal(t, 0, balance.Reserved)
+	assert.Equal(t, 500, balance.Withdrawn)
+}
+
+func TestStorage_HoldPoints_ConcurrentWithdraw(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	creditTestUser(t, s, userID, 1000)
+
+	for i := 0; i < 10; i++ {
+		orderID := fmt.Sprintf("%d-race-%d", userID, i)
+
+		var (
+			wg                   sync.WaitGroup
+			holdErr, withdrawErr error
+		)
+
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			_, holdErr = s.HoldPoints(ctx, entity.Hold{UserID: userID, OrderID: orderID, Sum: 10}, time.Minute)
+		}()
+
+		go func() {
+			defer wg.Done()
+			withdrawErr = s.Withdraw(ctx, entity.Withdraw{UserID: userID, OrderID: orderID, Sum: 10})
+		}()
+
+		wg.Wait()
+
+		// Exactly one of them gets the order number.
+		if holdErr == nil {
+			assert.ErrorIs(t, withdrawErr, ErrWithdrawalOrderExists)
+		} else {
+			assert.ErrorIs(t, holdErr, ErrWithdrawalOrderExists)
+			assert.NoError(t, withdrawErr)
+		}
+	}
+}
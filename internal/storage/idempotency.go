@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+const (
+	// idempotencyLockTimeout is how long a key stays locked by a request that
+	// never completed, e.g. because the instance handling it crashed.
+	idempotencyLockTimeout = time.Minute
+
+	// idempotencyKeyRetention is how long the outcome of a request is kept
+	// for retries with its key.
+	idempotencyKeyRetention = time.Hour * 24
+)
+
+// BeginIdempotentRequest locks the key for the request. It returns nil if the
+// caller holds the key now, or the record of an earlier request with it. A
+// key past its retention is taken over as a new one.
+func (s *Storage) BeginIdempotentRequest(ctx context.Context, rec entity.IdempotencyRecord) (*entity.IdempotencyRecord, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	insertQuery := `
+		INSERT INTO idempotency_keys 
+		    (user_id, 
+		     key, 
+		     request_hash, 
+		     expires_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP + ($5 * INTERVAL '1 millisecond'))
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+		    status_code = NULL,
+		    content_type = NULL,
+		    response_body = NULL,
+		    created_at = CURRENT_TIMESTAMP,
+		    expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.status_code IS NULL
+		  AND idempotency_keys.created_at < CURRENT_TIMESTAMP - ($4 * INTERVAL '1 millisecond')
+		   OR idempotency_keys.expires_at <= CURRENT_TIMESTAMP
+		RETURNING user_id`
+
+	var userID int
+	err := s.db.QueryRowContext(timeoutCtx, insertQuery,
+		rec.UserID, rec.Key, rec.RequestHash, idempotencyLockTimeout.Milliseconds(), idempotencyKeyRetention.Milliseconds()).
+		Scan(&userID)
+	if err == nil {
+		return nil, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	selectQuery := `
+		SELECT request_hash, 
+		       COALESCE(status_code, 0), 
+		       COALESCE(content_type, ''), 
+		       response_body
+		FROM idempotency_keys
+		WHERE user_id = $1
+		  AND key = $2`
+
+	existing := entity.IdempotencyRecord{
+		UserID: rec.UserID,
+		Key:    rec.Key,
+	}
+
+	err = s.db.QueryRowContext(timeoutCtx, selectQuery, rec.UserID, rec.Key).Scan(
+		&existing.RequestHash,
+		&existing.StatusCode,
+		&existing.ContentType,
+		&existing.ResponseBody)
+	if err != nil {
+		return nil, err
+	}
+
+	return &existing, nil
+}
+
+func (s *Storage) CompleteIdempotentRequest(ctx context.Context, rec entity.IdempotencyRecord) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $1,
+		    content_type = $2,
+		    response_body = $3
+		WHERE user_id = $4
+		  AND key = $5`
+
+	_, err := s.db.ExecContext(timeoutCtx, query, rec.StatusCode, rec.ContentType, rec.ResponseBody, rec.UserID, rec.Key)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey frees a key whose request failed, so it can be
+// retried with the same key.
+func (s *Storage) ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1
+		  AND key = $2
+		  AND status_code IS NULL`
+
+	_, err := s.db.ExecContext(timeoutCtx, query, userID, key)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes up to limit keys past their retention
+// and returns how many it deleted.
+func (s *Storage) DeleteExpiredIdempotencyKeys(ctx context.Context, limit int) (int, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE (user_id, key) IN (
+		    SELECT user_id, key
+		    FROM idempotency_keys
+		    WHERE expires_at <= CURRENT_TIMESTAMP
+		    LIMIT $1)`
+
+	result, err := s.db.ExecContext(timeoutCtx, query, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(deleted), nil
+}
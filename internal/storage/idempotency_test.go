@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func TestStorage_IdempotencyKeys_Expiry(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	rec := entity.IdempotencyRecord{UserID: userID, Key: "expiring", RequestHash: "first"}
+
+	existing, err := s.BeginIdempotentRequest(ctx, rec)
+	require.NoError(t, err)
+	require.Nil(t, existing)
+
+	rec.StatusCode = 200
+	require.NoError(t, s.CompleteIdempotentRequest(ctx, rec))
+
+	existing, err = s.BeginIdempotentRequest(ctx, entity.IdempotencyRecord{UserID: userID, Key: "expiring", RequestHash: "second"})
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, 200, existing.StatusCode)
+
+	_, err = s.db.ExecContext(ctx, `UPDATE idempotency_keys SET expires_at = CURRENT_TIMESTAMP WHERE user_id = $1`, userID)
+	require.NoError(t, err)
+
+	// A key past its retention is a new one.
+	existing, err = s.BeginIdempotentRequest(ctx, entity.IdempotencyRecord{UserID: userID, Key: "expiring", RequestHash: "second"})
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	_, err = s.db.ExecContext(ctx, `UPDATE idempotency_keys SET expires_at = CURRENT_TIMESTAMP WHERE user_id = $1`, userID)
+	require.NoError(t, err)
+
+	deleted, err := s.DeleteExpiredIdempotencyKeys(ctx, 1000)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, 1)
+
+	var left int
+	require.NoError(t, s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM idempotency_keys WHERE user_id = $1`, userID).Scan(&left))
+	assert.Equal(t, 0, left)
+}
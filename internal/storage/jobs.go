@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+// ClaimAccrualJobs locks up to limit due jobs and pushes their next run
+// forward by lease, so a job whose worker died becomes due again once the
+// lease runs out. The age of a job is counted from the upload of its order,
+// so a job queued again on restart doesn't start over.
+func (s *Storage) ClaimAccrualJobs(ctx context.Context, limit int, lease time.Duration) ([]entity.AccrualJob, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		UPDATE accrual_jobs j
+		SET attempts = j.attempts + 1,
+		    run_at = CURRENT_TIMESTAMP + ($2 * INTERVAL '1 millisecond')
+		FROM orders o
+		WHERE o.order_id = j.order_id
+		  AND j.order_id IN (
+		    SELECT order_id
+		    FROM accrual_jobs
+		    WHERE done_at IS NULL
+		      AND run_at <= CURRENT_TIMESTAMP
+		    ORDER BY run_at
+		    LIMIT $1
+		    FOR UPDATE SKIP LOCKED)
+		RETURNING j.order_id, 
+		          j.user_id, 
+		          j.attempts, 
+		          EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - o.uploaded_at)::bigint`
+
+	rows, err := s.db.QueryContext(timeoutCtx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var jobs []entity.AccrualJob
+	for rows.Next() {
+		var (
+			job        entity.AccrualJob
+			ageSeconds int64
+		)
+
+		err := rows.Scan(
+			&job.OrderID,
+			&job.UserID,
+			&job.Attempts,
+			&ageSeconds)
+		if err != nil {
+			return nil, err
+		}
+
+		job.Age = time.Duration(ageSeconds) * time.Second
+
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// EnqueueAccrualJobs makes the given orders due for an accrual check. Jobs
+// that already exist are left untouched unless they were marked done.
+func (s *Storage) EnqueueAccrualJobs(ctx context.Context, orders []entity.Order) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	orderIDs := make([]string, len(orders))
+	userIDs := make([]int, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.ID
+		userIDs[i] = order.UserID
+	}
+
+	query := `
+		INSERT INTO accrual_jobs 
+		    (order_id, 
+		     user_id)
+		SELECT * FROM unnest($1::varchar[], $2::int[])
+		ON CONFLICT (order_id) DO UPDATE
+		SET done_at = NULL,
+		    run_at = CURRENT_TIMESTAMP
+		WHERE accrual_jobs.done_at IS NOT NULL`
+
+	_, err := s.db.ExecContext(timeoutCtx, query, orderIDs, userIDs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) CountPendingAccrualJobs(ctx context.Context) (int, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		SELECT COUNT(*)
+		FROM accrual_jobs
+		WHERE done_at IS NULL
+		  AND run_at <= CURRENT_TIMESTAMP`
+
+	var count int
+	if err := s.db.QueryRowContext(timeoutCtx, query).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (s *Storage) RescheduleAccrualJob(ctx context.Context, orderID string, delay time.Duration) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		UPDATE accrual_jobs
+		SET run_at = CURRENT_TIMESTAMP + ($1 * INTERVAL '1 millisecond')
+		WHERE order_id = $2`
+
+	_, err := s.db.ExecContext(timeoutCtx, query, delay.Milliseconds(), orderID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) CompleteAccrualJob(ctx context.Context, orderID string) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		UPDATE accrual_jobs
+		SET done_at = CURRENT_TIMESTAMP
+		WHERE order_id = $1`
+
+	_, err := s.db.ExecContext(timeoutCtx, query, orderID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
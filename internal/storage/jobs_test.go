@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func TestStorage_ClaimAccrualJobs_AgeFromUpload(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	order := entity.Order{
+		ID:     fmt.Sprintf("%d", time.Now().UnixNano()),
+		UserID: userID,
+		Status: "NEW",
+	}
+	require.NoError(t, s.SaveOrder(ctx, order))
+
+	_, err := s.db.ExecContext(ctx, `UPDATE orders SET uploaded_at = uploaded_at - INTERVAL '2 hours' WHERE order_id = $1`, order.ID)
+	require.NoError(t, err)
+
+	// The job is queued now, as the recovery on startup does.
+	require.NoError(t, s.EnqueueAccrualJobs(ctx, []entity.Order{order}))
+
+	jobs, err := s.ClaimAccrualJobs(ctx, 1000, time.Minute)
+	require.NoError(t, err)
+
+	var found bool
+	for _, job := range jobs {
+		if job.OrderID == order.ID {
+			found = true
+			assert.GreaterOrEqual(t, job.Age, 2*time.Hour)
+		}
+	}
+
+	assert.True(t, found)
+}
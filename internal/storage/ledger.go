@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+var (
+	ErrInsufficientFunds          = errors.New("balance doesn't cover the debit")
+	ErrDuplicateLedgerTransaction = errors.New("ledger transaction was already posted")
+
+	errUnbalancedTransaction = errors.New("ledger transaction postings don't sum up to zero")
+)
+
+// withdrawnKinds are the transaction kinds that count towards the withdrawn
+// total of a balance. A reversal returns points, so it takes its sum off the
+// total again. Held points only count once the hold is captured. Points spent
+// on rewards count as withdrawn too.
+var withdrawnKinds = map[string]bool{
+	entity.LedgerWithdrawal: true,
+	entity.LedgerReversal:   true,
+	entity.LedgerCapture:    true,
+	entity.LedgerRedemption: true,
+}
+
+// ledgerTransaction identifies a ledger transaction by its kind and source.
+// reference is what the user sees next to the entry, usually an order number.
+type ledgerTransaction struct {
+	kind      string
+	sourceID  string
+	reference string
+}
+
+// posting is one side of a ledger transaction. userID is set for user
+// accounts only. restores is set for a credit that gives back the points of
+// an earlier debit, moves for a credit that takes over the lots the debit of
+// the same transaction spent. reference, if set, is what the user sees next
+// to the entry instead of the transaction reference.
+type posting struct {
+	account   string
+	userID    int
+	amount    int
+	restores  *ledgerTransaction
+	moves     bool
+	reference string
+}
+
+func userPosting(userID int, amount int) posting {
+	return posting{account: entity.AccountUser, userID: userID, amount: amount}
+}
+
+// restoringPosting credits the user with points the debit took, which go back
+// to the lots they were taken from.
+func restoringPosting(userID int, amount int, debit ledgerTransaction) posting {
+	return posting{account: entity.AccountUser, userID: userID, amount: amount, restores: &debit}
+}
+
+// movingPosting credits the user with the points an earlier posting of the
+// same transaction took from another user, which keep their earning time.
+func movingPosting(userID int, amount int, reference string) posting {
+	return posting{account: entity.AccountUser, userID: userID, amount: amount, moves: true, reference: reference}
+}
+
+func reservedPosting(userID int, amount int) posting {
+	return posting{account: entity.AccountReserved, userID: userID, amount: amount}
+}
+
+func systemPosting(account string, amount int) posting {
+	return posting{account: account, amount: amount}
+}
+
+// postLedgerTransaction writes a balanced set of postings within tx and
+// applies them to the balances and point lots of the users involved. Posting
+// a transaction with the same kind and source twice returns
+// ErrDuplicateLedgerTransaction. A posting that would take a user balance
+// below zero returns ErrInsufficientFunds.
+func (s *Storage) postLedgerTransaction(
+	ctx context.Context,
+	tx *sql.Tx,
+	lt ledgerTransaction,
+	postings ...posting,
+) (int64, error) {
+	var sum int
+	for _, p := range postings {
+		sum += p.amount
+	}
+
+	if sum != 0 {
+		return 0, errUnbalancedTransaction
+	}
+
+	transactionQuery := `
+		INSERT INTO ledger_transactions 
+		    (kind, 
+		     source_id, 
+		     reference)
+		VALUES ($1, $2, NULLIF($3, ''))
+		ON CONFLICT (kind, source_id) DO NOTHING
+		RETURNING id`
+
+	var transactionID int64
+	err := tx.QueryRowContext(ctx, transactionQuery, lt.kind, lt.sourceID, lt.reference).Scan(&transactionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrDuplicateLedgerTransaction
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	entryQuery := `
+		INSERT INTO ledger_entries 
+		    (transaction_id, 
+		     account, 
+		     user_id, 
+		     amount, 
+		     reference)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))`
+
+	for _, p := range postings {
+		var userID sql.NullInt64
+		if p.userID != 0 {
+			userID = sql.NullInt64{Int64: int64(p.userID), Valid: true}
+		}
+
+		_, err := tx.ExecContext(ctx, entryQuery, transactionID, p.account, userID, p.amount, p.reference)
+		if err != nil {
+			return 0, err
+		}
+
+		if p.userID != 0 {
+			if err := applyToBalance(ctx, tx, lt.kind, p); err != nil {
+				return 0, err
+			}
+		}
+
+		if p.account == entity.AccountUser {
+			if err := applyToPointLots(ctx, tx, transactionID, p); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return transactionID, nil
+}
+
+// applyToBalance keeps the balances table in step with the ledger. The
+// balance row doubles as the lock that serialises debits of one user.
+func applyToBalance(ctx context.Context, tx *sql.Tx, kind string, p posting) error {
+	current, reserved := p.amount, 0
+	if p.account == entity.AccountReserved {
+		current, reserved = 0, p.amount
+	}
+
+	var withdrawn int
+	if withdrawnKinds[kind] {
+		withdrawn = -p.amount
+	}
+
+	query := `
+		UPDATE balances 
+		SET current = current + $1,
+		    reserved = reserved + $2,
+		    withdrawn = withdrawn + $3
+		WHERE user_id = $4
+		  AND current + $1 >= 0
+		  AND reserved + $2 >= 0`
+
+	result, err := tx.ExecContext(ctx, query, current, reserved, withdrawn, p.userID)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok {
+			if pgErr.Code == checkViolationErrCode {
+				return ErrInsufficientFunds
+			}
+		}
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updated == 0 {
+		return ErrInsufficientFunds
+	}
+
+	return nil
+}
+
+func applyToPointLots(ctx context.Context, tx *sql.Tx, transactionID int64, p posting) error {
+	if p.amount < 0 {
+		return spendPointLots(ctx, tx, transactionID, p.userID, -p.amount)
+	}
+
+	amount := p.amount
+	if p.restores != nil {
+		restored, err := restorePointLots(ctx, tx, *p.restores)
+		if err != nil {
+			return err
+		}
+
+		amount -= restored
+	}
+
+	if p.moves {
+		moved, err := movePointLots(ctx, tx, transactionID, p.userID)
+		if err != nil {
+			return err
+		}
+
+		amount -= moved
+	}
+
+	if amount <= 0 {
+		return nil
+	}
+
+	return addPointLot(ctx, tx, transactionID, p.userID, amount)
+}
+
+// GetBalanceHistory returns the user's ledger entries created in [from, to)
+// in the order they were posted, each with the balance right after it. Zero
+// from or to leaves that side of the range open.
+func (s *Storage) GetBalanceHistory(ctx context.Context, userID int, from, to time.Time) ([]entity.LedgerEntry, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		SELECT id, 
+		       kind, 
+		       reference, 
+		       amount, 
+		       balance, 
+		       created_at
+		FROM (
+		    SELECT e.id, 
+		           t.kind, 
+		           COALESCE(e.reference, t.reference, '') AS reference, 
+		           e.amount, 
+		           SUM(e.amount) OVER (ORDER BY e.created_at, e.id) AS balance, 
+		           e.created_at
+		    FROM ledger_entries e
+		    JOIN ledger_transactions t ON t.id = e.transaction_id
+		    WHERE e.account = $2
+		      AND e.user_id = $1
+		) history
+		WHERE ($3::timestamp IS NULL OR created_at >= $3)
+		  AND ($4::timestamp IS NULL OR created_at < $4)
+		ORDER BY created_at, id`
+
+	rows, err := s.db.QueryContext(timeoutCtx, query, userID, entity.AccountUser, nullTime(from), nullTime(to))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var entries []entity.LedgerEntry
+	for rows.Next() {
+		var e entity.LedgerEntry
+
+		err := rows.Scan(
+			&e.ID,
+			&e.Kind,
+			&e.Reference,
+			&e.Amount,
+			&e.BalanceAfter,
+			&e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func TestStorage_CreditOrderAccrual_Once(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	order := entity.Order{
+		ID:     fmt.Sprintf("%d", time.Now().UnixNano()),
+		UserID: userID,
+		Status: "NEW",
+	}
+	require.NoError(t, s.SaveOrder(ctx, order))
+
+	order.Status = "PROCESSED"
+	order.Accrual = 50000
+
+	require.NoError(t, s.CreditOrderAccrual(ctx, order, entity.OrderCredit{}))
+	require.NoError(t, s.CreditOrderAccrual(ctx, order, entity.OrderCredit{}))
+
+	balance, err := s.GetBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 50000, balance.Current)
+}
+
+func TestStorage_postLedgerTransaction(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	tests := []struct {
+		name     string
+		postings []posting
+		wantErr  error
+	}{
+		{
+			name: "should reject postings that don't sum up to zero",
+			postings: []posting{
+				userPosting(userID, 100),
+				systemPosting(entity.AccountAdjustments, -99),
+			},
+			wantErr: errUnbalancedTransaction,
+		},
+		{
+			name: "should reject debit that takes balance below zero",
+			postings: []posting{
+				userPosting(userID, -100),
+				systemPosting(entity.AccountAdjustments, 100),
+			},
+			wantErr: ErrInsufficientFunds,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := s.db.BeginTx(ctx, nil)
+			require.NoError(t, err)
+			defer tx.Rollback()
+
+			_, err = s.postLedgerTransaction(ctx, tx, ledgerTransaction{
+				kind:     entity.LedgerAdjustment,
+				sourceID: tt.name,
+			}, tt.postings...)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("should reject the same transaction twice", func(t *testing.T) {
+		sourceID := fmt.Sprintf("duplicate:%d", time.Now().UnixNano())
+
+		for _, wantErr := range []error{nil, ErrDuplicateLedgerTransaction} {
+			tx, err := s.db.BeginTx(ctx, nil)
+			require.NoError(t, err)
+
+			_, err = s.postLedgerTransaction(ctx, tx, ledgerTransaction{
+				kind:     entity.LedgerAdjustment,
+				sourceID: sourceID,
+			},
+				userPosting(userID, 100),
+				systemPosting(entity.AccountAdjustments, -100),
+			)
+			if wantErr == nil {
+				require.NoError(t, err)
+				require.NoError(t, tx.Commit())
+				continue
+			}
+
+			assert.ErrorIs(t, err, wantErr)
+			tx.Rollback()
+		}
+	})
+}
+
+func TestStorage_GetBalanceHistory(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	creditTestUser(t, s, userID, 1000)
+	require.NoError(t, s.Withdraw(ctx, entity.Withdraw{
+		UserID:  userID,
+		OrderID: fmt.Sprintf("%d", time.Now().UnixNano()),
+		Sum:     300,
+	}))
+
+	history, err := s.GetBalanceHistory(ctx, userID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	assert.Equal(t, entity.LedgerAdjustment, history[0].Kind)
+	assert.Equal(t, 1000, history[0].BalanceAfter)
+	assert.Equal(t, entity.LedgerWithdrawal, history[1].Kind)
+	assert.Equal(t, -300, history[1].Amount)
+	assert.Equal(t, 700, history[1].BalanceAfter)
+}
@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+// Every credit of a user account starts a lot of points earned at the time of
+// the credit. Debits spend the oldest lots first, so whatever is left of a
+// lot once it gets old enough is what expires. Points a debit gives back, as
+// a released hold or a reversed withdrawal does, return to the lots the debit
+// took them from, so they keep their earning time. Points transferred to
+// another user go to new lots of theirs earned when the sender's were.
+
+func addPointLot(ctx context.Context, tx *sql.Tx, transactionID int64, userID int, amount int) error {
+	query := `
+		INSERT INTO point_lots 
+		    (user_id, 
+		     transaction_id, 
+		     amount, 
+		     remaining)
+		VALUES ($1, $2, $3, $3)`
+
+	_, err := tx.ExecContext(ctx, query, userID, transactionID, amount)
+
+	return err
+}
+
+// spendPointLots takes amount from the user's oldest lots and records what
+// the transaction took from each. It relies on the caller holding the lock on
+// the user's balance row.
+func spendPointLots(ctx context.Context, tx *sql.Tx, transactionID int64, userID int, amount int) error {
+	query := `
+		WITH spent AS (
+		    UPDATE point_lots l
+		    SET remaining = l.remaining - LEAST(o.remaining, $2 - o.spent_before)
+		    FROM (
+		        SELECT id, 
+		               remaining, 
+		               SUM(remaining) OVER (ORDER BY earned_at, id) - remaining AS spent_before
+		        FROM point_lots
+		        WHERE user_id = $1
+		          AND remaining > 0
+		    ) o
+		    WHERE l.id = o.id
+		      AND o.spent_before < $2
+		    RETURNING l.id, LEAST(o.remaining, $2 - o.spent_before) AS amount
+		)
+		INSERT INTO point_lot_spends 
+		    (transaction_id, 
+		     lot_id, 
+		     amount)
+		SELECT $3::bigint, id, amount
+		FROM spent`
+
+	_, err := tx.ExecContext(ctx, query, userID, amount, transactionID)
+
+	return err
+}
+
+// restorePointLots gives the points the debit took back to the lots it took
+// them from and returns how many points it restored.
+func restorePointLots(ctx context.Context, tx *sql.Tx, debit ledgerTransaction) (int, error) {
+	query := `
+		WITH restored AS (
+		    UPDATE point_lots l
+		    SET remaining = l.remaining + s.amount
+		    FROM point_lot_spends s
+		    JOIN ledger_transactions t ON t.id = s.transaction_id
+		    WHERE t.kind = $1
+		      AND t.source_id = $2
+		      AND l.id = s.lot_id
+		    RETURNING s.amount
+		)
+		SELECT COALESCE(SUM(amount), 0)
+		FROM restored`
+
+	var restored int
+	err := tx.QueryRowContext(ctx, query, debit.kind, debit.sourceID).Scan(&restored)
+
+	return restored, err
+}
+
+// movePointLots gives the user new lots for what the transaction took from
+// the lots of another user, earned when those were, and returns how many
+// points it moved.
+func movePointLots(ctx context.Context, tx *sql.Tx, transactionID int64, userID int) (int, error) {
+	query := `
+		WITH moved AS (
+		    INSERT INTO point_lots 
+		        (user_id, 
+		         transaction_id, 
+		         amount, 
+		         remaining, 
+		         earned_at)
+		    SELECT $2, s.transaction_id, s.amount, s.amount, l.earned_at
+		    FROM point_lot_spends s
+		    JOIN point_lots l ON l.id = s.lot_id
+		    WHERE s.transaction_id = $1
+		      AND l.user_id <> $2
+		    RETURNING amount
+		)
+		SELECT COALESCE(SUM(amount), 0)
+		FROM moved`
+
+	var moved int
+	err := tx.QueryRowContext(ctx, query, transactionID, userID).Scan(&moved)
+
+	return moved, err
+}
+
+// ExpirePoints writes off what is left of the lots earned more than ttl ago,
+// for up to limit users at a time, and returns the number of users whose
+// points expired.
+func (s *Storage) ExpirePoints(ctx context.Context, ttl time.Duration, limit int) (int, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	usersQuery := `
+		SELECT DISTINCT user_id
+		FROM point_lots
+		WHERE remaining > 0
+		  AND earned_at < CURRENT_TIMESTAMP - ($1 * INTERVAL '1 millisecond')
+		LIMIT $2`
+
+	rows, err := s.db.QueryContext(timeoutCtx, usersQuery, ttl.Milliseconds(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		userIDs = append(userIDs, userID)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, userID := range userIDs {
+		if err := s.expireUserPoints(ctx, userID, ttl); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(userIDs), nil
+}
+
+func (s *Storage) expireUserPoints(ctx context.Context, userID int, ttl time.Duration) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	lockQuery := `
+		SELECT user_id
+		FROM balances
+		WHERE user_id = $1
+		FOR UPDATE`
+
+	if _, err := tx.ExecContext(timeoutCtx, lockQuery, userID); err != nil {
+		return err
+	}
+
+	lotsQuery := `
+		SELECT id, 
+		       remaining
+		FROM point_lots
+		WHERE user_id = $1
+		  AND remaining > 0
+		  AND earned_at < CURRENT_TIMESTAMP - ($2 * INTERVAL '1 millisecond')
+		ORDER BY earned_at, id`
+
+	rows, err := tx.QueryContext(timeoutCtx, lotsQuery, userID, ttl.Milliseconds())
+	if err != nil {
+		return err
+	}
+
+	var (
+		lotIDs []int64
+		amount int
+	)
+
+	for rows.Next() {
+		var (
+			lotID     int64
+			remaining int
+		)
+
+		if err := rows.Scan(&lotID, &remaining); err != nil {
+			rows.Close()
+			return err
+		}
+
+		lotIDs = append(lotIDs, lotID)
+		amount += remaining
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if amount == 0 {
+		return nil
+	}
+
+	// A lot that already expired can be refilled by a reversed debit and
+	// expire again, so every run of the user's expiry gets its own key.
+	lt := ledgerTransaction{
+		kind:     entity.LedgerExpiry,
+		sourceID: fmt.Sprintf("%d:%d", userID, time.Now().UnixNano()),
+	}
+
+	_, err = s.postLedgerTransaction(timeoutCtx, tx, lt,
+		userPosting(userID, -amount),
+		systemPosting(entity.AccountExpired, amount),
+	)
+	if err != nil {
+		return err
+	}
+
+	markQuery := `
+		UPDATE point_lots
+		SET expired_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($1)`
+
+	if _, err := tx.ExecContext(timeoutCtx, markQuery, lotIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetExpiringPoints returns how many of the user's points were earned more
+// than age ago and are still unspent, and when the oldest of them was earned.
+func (s *Storage) GetExpiringPoints(ctx context.Context, userID int, age time.Duration) (*entity.ExpiringPoints, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		SELECT COALESCE(SUM(remaining), 0), 
+		       MIN(earned_at)
+		FROM point_lots
+		WHERE user_id = $1
+		  AND remaining > 0
+		  AND earned_at < CURRENT_TIMESTAMP - ($2 * INTERVAL '1 millisecond')`
+
+	var earnedAt sql.NullTime
+
+	points := entity.ExpiringPoints{UserID: userID}
+	err := s.db.QueryRowContext(timeoutCtx, query, userID, age.Milliseconds()).Scan(&points.Amount, &earnedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	points.EarnedAt = earnedAt.Time
+
+	return &points, nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func TestStorage_PointLots(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	creditTestUser(t, s, userID, 500)
+	creditTestUser(t, s, userID, 300)
+
+	err := s.Withdraw(ctx, entity.Withdraw{UserID: userID, OrderID: fmt.Sprintf("%d-lots", userID), Sum: 600})
+	require.NoError(t, err)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT remaining FROM point_lots WHERE user_id = $1 ORDER BY id`, userID)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var remaining []int
+	for rows.Next() {
+		var r int
+		require.NoError(t, rows.Scan(&r))
+		remaining = append(remaining, r)
+	}
+	require.NoError(t, rows.Err())
+
+	assert.Equal(t, []int{0, 200}, remaining)
+
+	expiring, err := s.GetExpiringPoints(ctx, userID, -time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 200, expiring.Amount)
+
+	require.NoError(t, s.expireUserPoints(ctx, userID, -time.Minute))
+
+	balance, err := s.GetBalance(ctx, userID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, balance.Current)
+	assert.Equal(t, 600, balance.Withdrawn)
+
+	history, err := s.GetBalanceHistory(ctx, userID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.NotEmpty(t, history)
+
+	assert.Equal(t, entity.LedgerExpiry, history[len(history)-1].Kind)
+	assert.Equal(t, -200, history[len(history)-1].Amount)
+}
+
+func TestStorage_PointLots_ReturnedPointsKeepEarningTime(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	creditTestUser(t, s, userID, 1000)
+
+	_, err := s.db.ExecContext(ctx, `UPDATE point_lots SET earned_at = earned_at - INTERVAL '2 hours' WHERE user_id = $1`, userID)
+	require.NoError(t, err)
+
+	released := fmt.Sprintf("%d-released", userID)
+	expired := fmt.Sprintf("%d-expired", userID)
+	reversed := fmt.Sprintf("%d-reversed", userID)
+
+	_, err = s.HoldPoints(ctx, entity.Hold{UserID: userID, OrderID: released, Sum: 500}, time.Minute)
+	require.NoError(t, err)
+
+	_, err = s.ReleaseHold(ctx, userID, released)
+	require.NoError(t, err)
+
+	_, err = s.HoldPoints(ctx, entity.Hold{UserID: userID, OrderID: expired, Sum: 300}, -time.Minute)
+	require.NoError(t, err)
+
+	_, err = s.ExpireHolds(ctx, 100)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Withdraw(ctx, entity.Withdraw{UserID: userID, OrderID: reversed, Sum: 200}))
+
+	_, err = s.ReverseWithdrawal(ctx, reversed)
+	require.NoError(t, err)
+
+	var lots int
+	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM point_lots WHERE user_id = $1`, userID).Scan(&lots)
+	require.NoError(t, err)
+	assert.Equal(t, 1, lots)
+
+	expiring, err := s.GetExpiringPoints(ctx, userID, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, expiring.Amount)
+
+	require.NoError(t, s.expireUserPoints(ctx, userID, time.Hour))
+
+	balance, err := s.GetBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, balance.Current)
+}
+
+func TestStorage_PointLots_ReversedAfterExpiry(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	creditTestUser(t, s, userID, 1000)
+
+	_, err := s.db.ExecContext(ctx, `UPDATE point_lots SET earned_at = earned_at - INTERVAL '2 hours' WHERE user_id = $1`, userID)
+	require.NoError(t, err)
+
+	orderID := fmt.Sprintf("%d-reversed", userID)
+	require.NoError(t, s.Withdraw(ctx, entity.Withdraw{UserID: userID, OrderID: orderID, Sum: 200}))
+
+	require.NoError(t, s.expireUserPoints(ctx, userID, time.Hour))
+
+	// The reversal refills the lot that has just expired, so it expires again.
+	_, err = s.ReverseWithdrawal(ctx, orderID)
+	require.NoError(t, err)
+
+	require.NoError(t, s.expireUserPoints(ctx, userID, time.Hour))
+
+	balance, err := s.GetBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, balance.Current)
+
+	history, err := s.GetBalanceHistory(ctx, userID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.NotEmpty(t, history)
+
+	assert.Equal(t, entity.LedgerExpiry, history[len(history)-1].Kind)
+	assert.Equal(t, -200, history[len(history)-1].Amount)
+}
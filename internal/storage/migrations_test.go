@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSchema connects to the database from TEST_DATABASE_URI with a fresh
+// schema of its own, so migrations can be run from scratch, and skips the
+// test when it is not set.
+func newTestSchema(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URI")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URI is not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+
+	// search_path is a session setting, so it has to stay on one connection.
+	db.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("migration_test_%d", time.Now().UnixNano())
+
+	_, err = db.Exec("CREATE SCHEMA " + schema)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		db.Close()
+	})
+
+	_, err = db.Exec("SET search_path TO " + schema)
+	require.NoError(t, err)
+
+	require.NoError(t, goose.SetDialect("pgx"))
+
+	return db
+}
+
+func TestMigration_withdrawalsOrderIDUnique(t *testing.T) {
+	const (
+		before  = 20230901100000
+		version = 20230903100000
+	)
+
+	db := newTestSchema(t)
+
+	require.NoError(t, goose.UpTo(db, "../../migrations", before))
+
+	_, err := db.Exec(`
+		INSERT INTO withdrawals (user_id, order_id, sum, processed_at)
+		VALUES (1, '12345678903', 100, '2023-09-01 10:00'),
+		       (1, '12345678903', 200, '2023-09-01 11:00'),
+		       (2, '12345678903', 300, '2023-09-01 09:00'),
+		       (1, '2377225624', 400, '2023-09-01 10:00')`)
+	require.NoError(t, err)
+
+	require.NoError(t, goose.UpTo(db, "../../migrations", version))
+
+	assert.Equal(t, []int{300, 400}, querySums(t, db, "SELECT sum FROM withdrawals ORDER BY sum"))
+	assert.Equal(t, []int{100, 200}, querySums(t, db, "SELECT sum FROM withdrawals_duplicates ORDER BY sum"))
+
+	_, err = db.Exec(`INSERT INTO withdrawals (user_id, order_id, sum) VALUES (1, '2377225624', 500)`)
+	assert.Error(t, err)
+
+	require.NoError(t, goose.DownTo(db, "../../migrations", before))
+
+	assert.Equal(t, []int{100, 200, 300, 400}, querySums(t, db, "SELECT sum FROM withdrawals ORDER BY sum"))
+}
+
+func TestMigration_balancesCurrentCheck(t *testing.T) {
+	const (
+		before  = 20230822120000
+		version = 20230824090000
+	)
+
+	db := newTestSchema(t)
+
+	require.NoError(t, goose.UpTo(db, "../../migrations", before))
+
+	_, err := db.Exec(`
+		INSERT INTO balances (user_id, current, withdrawn)
+		VALUES (1, -150, 650),
+		       (2, 300, 0)`)
+	require.NoError(t, err)
+
+	require.NoError(t, goose.UpTo(db, "../../migrations", version))
+
+	assert.Equal(t, []int{0, 300}, querySums(t, db, "SELECT current FROM balances ORDER BY user_id"))
+	assert.Equal(t, []int{150}, querySums(t, db, "SELECT deficit FROM balances_deficits"))
+
+	_, err = db.Exec(`UPDATE balances SET current = -1 WHERE user_id = 2`)
+	assert.Error(t, err)
+
+	require.NoError(t, goose.DownTo(db, "../../migrations", before))
+
+	assert.Equal(t, []int{-150, 300}, querySums(t, db, "SELECT current FROM balances ORDER BY user_id"))
+}
+
+func querySums(t *testing.T, db *sql.DB, query string) []int {
+	t.Helper()
+
+	rows, err := db.Query(query)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var sums []int
+	for rows.Next() {
+		var sum int
+		require.NoError(t, rows.Scan(&sum))
+		sums = append(sums, sum)
+	}
+	require.NoError(t, rows.Err())
+
+	return sums
+}
@@ -2,8 +2,12 @@ package storage
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
 )
 
@@ -15,22 +19,32 @@ func (s *Storage) GetOrder(ctx context.Context, orderID string) (*entity.Order,
 		SELECT order_id, 
 		       user_id, 
 		       accrual, 
-		       status
+		       status, 
+		       uploaded_at, 
+		       credited_at
 		FROM orders
 		WHERE order_id = $1`
 
 	row := s.db.QueryRowContext(timeoutCtx, query, orderID)
 
-	var order entity.Order
+	var (
+		order      entity.Order
+		creditedAt sql.NullTime
+	)
+
 	err := row.Scan(
 		&order.ID,
 		&order.UserID,
 		&order.Accrual,
-		&order.Status)
+		&order.Status,
+		&order.UploadedAt,
+		&creditedAt)
 	if err != nil {
 		return nil, err
 	}
 
+	order.CreditedAt = creditedAt.Time
+
 	return &order, nil
 }
 
@@ -38,7 +52,13 @@ func (s *Storage) SaveOrder(ctx context.Context, order entity.Order) error {
 	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
 	defer cancel()
 
-	query := `
+	tx, err := s.db.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	orderQuery := `
 		INSERT INTO orders 
 		    (order_id, 
 		     user_id, 
@@ -46,12 +66,84 @@ func (s *Storage) SaveOrder(ctx context.Context, order entity.Order) error {
 		     status)
 		VALUES ($1, $2, $3, $4)`
 
-	_, err := s.db.ExecContext(timeoutCtx, query, order.ID, order.UserID, order.Accrual, order.Status)
+	_, err = tx.ExecContext(timeoutCtx, orderQuery, order.ID, order.UserID, order.Accrual, order.Status)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	jobQuery := `
+		INSERT INTO accrual_jobs 
+		    (order_id, 
+		     user_id)
+		VALUES ($1, $2)`
+
+	_, err = tx.ExecContext(timeoutCtx, jobQuery, order.ID, order.UserID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SaveOrders stores the user's new orders and queues them for accrual in a
+// single round trip. Orders that already exist are left as they are. The
+// outcome is reported for each distinct order id; an order created by a
+// concurrent upload after the statement started comes back with no owner.
+func (s *Storage) SaveOrders(ctx context.Context, userID int, orderIDs []string, status string) ([]entity.OrderUpload, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		WITH input AS (
+		    SELECT DISTINCT order_id
+		    FROM unnest($1::varchar[]) AS order_id
+		), inserted AS (
+		    INSERT INTO orders 
+		        (order_id, 
+		         user_id, 
+		         accrual, 
+		         status)
+		    SELECT order_id, $2, 0, $3
+		    FROM input
+		    ON CONFLICT (order_id) DO NOTHING
+		    RETURNING order_id, user_id
+		), jobs AS (
+		    INSERT INTO accrual_jobs 
+		        (order_id, 
+		         user_id)
+		    SELECT order_id, user_id
+		    FROM inserted
+		)
+		SELECT i.order_id, 
+		       COALESCE(ins.user_id, o.user_id, 0), 
+		       ins.order_id IS NOT NULL
+		FROM input i
+		LEFT JOIN inserted ins ON ins.order_id = i.order_id
+		LEFT JOIN orders o ON o.order_id = i.order_id`
+
+	rows, err := s.db.QueryContext(timeoutCtx, query, orderIDs, userID, status)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var uploads []entity.OrderUpload
+	for rows.Next() {
+		var upload entity.OrderUpload
+
+		if err := rows.Scan(&upload.OrderID, &upload.OwnerID, &upload.Created); err != nil {
+			return nil, err
+		}
+
+		uploads = append(uploads, upload)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return uploads, nil
 }
 
 func (s *Storage) UpdateOrder(order entity.Order) error {
@@ -62,7 +154,8 @@ func (s *Storage) UpdateOrder(order entity.Order) error {
 		UPDATE orders 
 		SET accrual = $1,
 		    status = $2
-		WHERE order_id = $3`
+		WHERE order_id = $3
+		  AND credited_at IS NULL`
 
 	_, err := s.db.ExecContext(ctx, query, order.Accrual, order.Status, order.ID)
 	if err != nil {
@@ -72,7 +165,123 @@ func (s *Storage) UpdateOrder(order entity.Order) error {
 	return nil
 }
 
-func (s *Storage) GetUserOrders(ctx context.Context, userID int) ([]entity.Order, error) {
+// CreditOrderAccrual stores the final order status and posts the accrual to
+// the owner's ledger account in one transaction, along with the bonuses and
+// the tier change the accrual brings. An order is credited at most once:
+// repeated calls for an already credited order change nothing.
+func (s *Storage) CreditOrderAccrual(ctx context.Context, order entity.Order, credit entity.OrderCredit) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	orderQuery := `
+		UPDATE orders 
+		SET accrual = $1,
+		    status = $2,
+		    credited_at = CURRENT_TIMESTAMP
+		WHERE order_id = $3
+		  AND credited_at IS NULL
+		RETURNING user_id`
+
+	var userID int
+	err = tx.QueryRowContext(timeoutCtx, orderQuery, order.Accrual, order.Status, order.ID).Scan(&userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if err == nil {
+		if err := s.postOrderCredit(timeoutCtx, tx, userID, order, credit); err != nil {
+			return err
+		}
+	}
+
+	jobQuery := `
+		UPDATE accrual_jobs
+		SET done_at = CURRENT_TIMESTAMP
+		WHERE order_id = $1`
+
+	_, err = tx.ExecContext(timeoutCtx, jobQuery, order.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetUserOrders returns a page of the user's orders selected by filter,
+// sorted by upload time.
+func (s *Storage) GetUserOrders(ctx context.Context, userID int, filter entity.OrderFilter) ([]entity.Order, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	op, dir := pageOrder(filter.Page)
+	after, afterID := pageCursor(filter.Page)
+
+	query := fmt.Sprintf(`
+		SELECT order_id, 
+		       accrual, 
+		       status, 
+		       uploaded_at, 
+		       credited_at
+		FROM orders
+		WHERE user_id = $1
+		  AND ($2::varchar[] IS NULL OR status = ANY($2))
+		  AND ($3::timestamp IS NULL OR uploaded_at >= $3)
+		  AND ($4::timestamp IS NULL OR uploaded_at < $4)
+		  AND ($5::timestamp IS NULL OR (uploaded_at, order_id) %[1]s ($5, $6))
+		ORDER BY uploaded_at %[2]s, order_id %[2]s
+		LIMIT $7`, op, dir)
+
+	var statuses []string
+	if len(filter.Statuses) > 0 {
+		statuses = filter.Statuses
+	}
+
+	rows, err := s.db.QueryContext(timeoutCtx, query,
+		userID, statuses, nullTime(filter.From), nullTime(filter.To), after, afterID, pageLimit(filter.Page))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var orders []entity.Order
+	for rows.Next() {
+		var (
+			order      = entity.Order{UserID: userID}
+			creditedAt sql.NullTime
+		)
+
+		err := rows.Scan(
+			&order.ID,
+			&order.Accrual,
+			&order.Status,
+			&order.UploadedAt,
+			&creditedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		order.CreditedAt = creditedAt.Time
+
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// GetUserOrdersByStatus returns the user's orders in one of the given
+// statuses, oldest first.
+func (s *Storage) GetUserOrdersByStatus(ctx context.Context, userID int, statuses []string) ([]entity.Order, error) {
 	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
 	defer cancel()
 
@@ -83,9 +292,79 @@ func (s *Storage) GetUserOrders(ctx context.Context, userID int) ([]entity.Order
 		       uploaded_at
 		FROM orders
 		WHERE user_id = $1
+		  AND status = ANY($2)
 		ORDER BY uploaded_at ASC`
 
-	rows, err := s.db.QueryContext(timeoutCtx, query, userID)
+	rows, err := s.db.QueryContext(timeoutCtx, query, userID, statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var orders []entity.Order
+	for rows.Next() {
+		order := entity.Order{UserID: userID}
+
+		err := rows.Scan(
+			&order.ID,
+			&order.Accrual,
+			&order.Status,
+			&order.UploadedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// CountUserOrdersByStatus returns the number of the user's orders in one of
+// the given statuses.
+func (s *Storage) CountUserOrdersByStatus(ctx context.Context, userID int, statuses []string) (int, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		SELECT COUNT(*)
+		FROM orders
+		WHERE user_id = $1
+		  AND status = ANY($2)`
+
+	var count int
+	if err := s.db.QueryRowContext(timeoutCtx, query, userID, statuses).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetOrdersByStatus returns up to limit orders in one of the given statuses
+// ordered by order id, starting after afterOrderID, so callers can page
+// through the table without loading it at once.
+func (s *Storage) GetOrdersByStatus(ctx context.Context, statuses []string, afterOrderID string, limit int) ([]entity.Order, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		SELECT order_id, 
+		       user_id, 
+		       accrual, 
+		       status, 
+		       uploaded_at
+		FROM orders
+		WHERE status = ANY($1)
+		  AND order_id > $2
+		ORDER BY order_id
+		LIMIT $3`
+
+	rows, err := s.db.QueryContext(timeoutCtx, query, statuses, afterOrderID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -98,6 +377,7 @@ func (s *Storage) GetUserOrders(ctx context.Context, userID int) ([]entity.Order
 
 		err := rows.Scan(
 			&order.ID,
+			&order.UserID,
 			&order.Accrual,
 			&order.Status,
 			&order.UploadedAt)
@@ -114,3 +394,40 @@ func (s *Storage) GetUserOrders(ctx context.Context, userID int) ([]entity.Order
 
 	return orders, nil
 }
+
+// postOrderCredit posts the accrual of a newly credited order and what comes
+// with it within tx.
+func (s *Storage) postOrderCredit(ctx context.Context, tx *sql.Tx, userID int, order entity.Order, credit entity.OrderCredit) error {
+	// The referrer's balance has to be locked before the accrual locks the
+	// user's one.
+	referralID, referrerID, err := lockPendingReferral(ctx, tx, userID, credit.Referral)
+	if err != nil {
+		return err
+	}
+
+	if order.Accrual > 0 {
+		lt := ledgerTransaction{
+			kind:      entity.LedgerAccrual,
+			sourceID:  order.ID,
+			reference: order.ID,
+		}
+
+		_, err := s.postLedgerTransaction(ctx, tx, lt,
+			userPosting(userID, order.Accrual),
+			systemPosting(entity.AccountAccruals, -order.Accrual),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.applyTierCredit(ctx, tx, userID, order, credit.Tiers); err != nil {
+		return err
+	}
+
+	if err := s.applyCampaignBonuses(ctx, tx, userID, order, credit.CampaignBonuses); err != nil {
+		return err
+	}
+
+	return s.applyReferralBonus(ctx, tx, userID, referralID, referrerID, credit.Referral)
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func TestStorage_SaveOrders(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+	otherID := createTestUser(t, s)
+
+	prefix := fmt.Sprintf("%d", time.Now().UnixNano())
+	own, foreign, fresh := prefix+"1", prefix+"2", prefix+"3"
+
+	require.NoError(t, s.SaveOrder(ctx, entity.Order{ID: own, UserID: userID, Status: "NEW"}))
+	require.NoError(t, s.SaveOrder(ctx, entity.Order{ID: foreign, UserID: otherID, Status: "NEW"}))
+
+	uploads, err := s.SaveOrders(ctx, userID, []string{own, foreign, fresh, fresh}, "NEW")
+	require.NoError(t, err)
+
+	got := make(map[string]entity.OrderUpload)
+	for _, u := range uploads {
+		got[u.OrderID] = u
+	}
+
+	assert.Len(t, uploads, 3)
+	assert.Equal(t, entity.OrderUpload{OrderID: own, OwnerID: userID}, got[own])
+	assert.Equal(t, entity.OrderUpload{OrderID: foreign, OwnerID: otherID}, got[foreign])
+	assert.Equal(t, entity.OrderUpload{OrderID: fresh, OwnerID: userID, Created: true}, got[fresh])
+
+	order, err := s.GetOrder(ctx, fresh)
+	require.NoError(t, err)
+	assert.Equal(t, userID, order.UserID)
+}
+
+func TestStorage_GetOrder(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+	orderID := fmt.Sprintf("%d", time.Now().UnixNano())
+
+	require.NoError(t, s.SaveOrder(ctx, entity.Order{ID: orderID, UserID: userID, Status: "NEW"}))
+
+	order, err := s.GetOrder(ctx, orderID)
+	require.NoError(t, err)
+
+	assert.Equal(t, userID, order.UserID)
+	assert.False(t, order.UploadedAt.IsZero())
+	assert.True(t, order.CreditedAt.IsZero())
+}
+
+func TestStorage_CountUserOrdersByStatus(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	for _, status := range []string{"NEW", "PROCESSING", "PROCESSED"} {
+		order := entity.Order{
+			ID:     fmt.Sprintf("%d", time.Now().UnixNano()),
+			UserID: userID,
+			Status: status,
+		}
+		require.NoError(t, s.SaveOrder(ctx, order))
+	}
+
+	count, err := s.CountUserOrdersByStatus(ctx, userID, []string{"NEW", "REGISTERED", "PROCESSING"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestStorage_GetUserOrders_CreditedAt(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	order := entity.Order{
+		ID:     fmt.Sprintf("%d", time.Now().UnixNano()),
+		UserID: userID,
+		Status: "NEW",
+	}
+	require.NoError(t, s.SaveOrder(ctx, order))
+
+	order.Status = "PROCESSED"
+	order.Accrual = 500
+	require.NoError(t, s.CreditOrderAccrual(ctx, order, entity.OrderCredit{}))
+
+	orders, err := s.GetUserOrders(ctx, userID, entity.OrderFilter{})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.False(t, orders[0].CreditedAt.IsZero())
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+// pageOrder returns the operator that picks the rows after the page cursor
+// and the sort direction of the page.
+func pageOrder(p entity.Page) (op, dir string) {
+	if p.Desc {
+		return "<", "DESC"
+	}
+
+	return ">", "ASC"
+}
+
+// pageLimit returns the limit of the page, null for a page of all the rows.
+func pageLimit(p entity.Page) sql.NullInt64 {
+	if p.Limit == 0 {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: int64(p.Limit), Valid: true}
+}
+
+// pageCursor returns the position the page starts after, null for the first
+// page.
+func pageCursor(p entity.Page) (sql.NullTime, string) {
+	if p.After == nil {
+		return sql.NullTime{}, ""
+	}
+
+	return sql.NullTime{Time: p.After.At, Valid: true}, p.After.ID
+}
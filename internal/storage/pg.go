@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/pressly/goose/v3"
@@ -13,23 +14,65 @@ import (
 
 const contextTimeoutSeconds = 3
 
-const uniqueViolationErrCode = "23505"
+const (
+	uniqueViolationErrCode = "23505"
+	checkViolationErrCode  = "23514"
+)
 
 type Repository interface {
 	SaveUser(ctx context.Context, user entity.User) (int, error)
 	GetUser(ctx context.Context, login string) (*entity.User, error)
+	GetUserByReferralCode(ctx context.Context, code string) (*entity.User, error)
+	GetReferralStats(ctx context.Context, userID int) (*entity.ReferralStats, error)
 
 	SaveOrder(ctx context.Context, order entity.Order) error
+	SaveOrders(ctx context.Context, userID int, orderIDs []string, status string) ([]entity.OrderUpload, error)
 	GetOrder(ctx context.Context, orderID string) (*entity.Order, error)
-	GetUserOrders(ctx context.Context, userID int) ([]entity.Order, error)
+	GetUserOrders(ctx context.Context, userID int, filter entity.OrderFilter) ([]entity.Order, error)
+	GetUserOrdersByStatus(ctx context.Context, userID int, statuses []string) ([]entity.Order, error)
+	CountUserOrdersByStatus(ctx context.Context, userID int, statuses []string) (int, error)
 	UpdateOrder(order entity.Order) error
+	CreditOrderAccrual(ctx context.Context, order entity.Order, credit entity.OrderCredit) error
+	GetOrdersByStatus(ctx context.Context, statuses []string, afterOrderID string, limit int) ([]entity.Order, error)
 
 	CreateBalance(ctx context.Context, userID int) error
 	GetBalance(ctx context.Context, userID int) (*entity.Balance, error)
-	UpdateBalance(amount int, userID int) error
+	GetBalanceHistory(ctx context.Context, userID int, from, to time.Time) ([]entity.LedgerEntry, error)
+	GetLifetimeAccrual(ctx context.Context, userID int) (int, error)
+	GetExpiringPoints(ctx context.Context, userID int, age time.Duration) (*entity.ExpiringPoints, error)
+	ExpirePoints(ctx context.Context, ttl time.Duration, limit int) (int, error)
 
 	Withdraw(ctx context.Context, w entity.Withdraw) error
-	GetUserWithdrawals(ctx context.Context, userID int) ([]entity.Withdraw, error)
+	GetUserWithdrawals(ctx context.Context, userID int, filter entity.WithdrawalFilter) ([]entity.Withdraw, error)
+	ReverseWithdrawal(ctx context.Context, orderID string) (*entity.Withdraw, error)
+	Transfer(ctx context.Context, t entity.Transfer, dailyLimit int) error
+
+	HoldPoints(ctx context.Context, h entity.Hold, ttl time.Duration) (*entity.Hold, error)
+	CaptureHold(ctx context.Context, userID int, orderID string) (*entity.Hold, error)
+	ReleaseHold(ctx context.Context, userID int, orderID string) (*entity.Hold, error)
+	ExpireHolds(ctx context.Context, limit int) (int, error)
+
+	SaveReward(ctx context.Context, r entity.Reward) (*entity.Reward, error)
+	UpdateReward(ctx context.Context, r entity.Reward) (*entity.Reward, error)
+	GetRewards(ctx context.Context, all bool) ([]entity.Reward, error)
+	Redeem(ctx context.Context, userID int, rewardID int) (*entity.Redemption, error)
+	GetUserRedemptions(ctx context.Context, userID int) ([]entity.Redemption, error)
+
+	SaveCampaign(ctx context.Context, c entity.Campaign) (*entity.Campaign, error)
+	GetCampaigns(ctx context.Context) ([]entity.Campaign, error)
+	GetActiveCampaigns(ctx context.Context) ([]entity.Campaign, error)
+	CountCreditedOrders(ctx context.Context, userID int) (int, error)
+
+	BeginIdempotentRequest(ctx context.Context, rec entity.IdempotencyRecord) (*entity.IdempotencyRecord, error)
+	CompleteIdempotentRequest(ctx context.Context, rec entity.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, limit int) (int, error)
+
+	EnqueueAccrualJobs(ctx context.Context, orders []entity.Order) error
+	ClaimAccrualJobs(ctx context.Context, limit int, lease time.Duration) ([]entity.AccrualJob, error)
+	CountPendingAccrualJobs(ctx context.Context) (int, error)
+	RescheduleAccrualJob(ctx context.Context, orderID string, delay time.Duration) error
+	CompleteAccrualJob(ctx context.Context, orderID string) error
 }
 
 type Storage struct {
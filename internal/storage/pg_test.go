@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/logger"
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+// newTestStorage connects to the database from TEST_DATABASE_URI and skips
+// the test when it is not set.
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URI")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URI is not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	require.NoError(t, goose.SetDialect("pgx"))
+	require.NoError(t, goose.Up(db, "../../migrations"))
+
+	return &Storage{
+		db:     db,
+		logger: logger.NewLogger(),
+	}
+}
+
+// createTestUser saves a user with a unique login and an empty balance.
+func createTestUser(t *testing.T, s *Storage) int {
+	t.Helper()
+
+	ctx := context.Background()
+
+	userID, err := s.SaveUser(ctx, entity.User{
+		Login:        fmt.Sprintf("%s_%d", t.Name(), time.Now().UnixNano()),
+		PasswordHash: "hash",
+		ReferralCode: fmt.Sprintf("T%d", time.Now().UnixNano()%1e15),
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.CreateBalance(ctx, userID))
+
+	return userID
+}
+
+// creditTestUser adds amount to the user's balance through a ledger
+// adjustment.
+func creditTestUser(t *testing.T, s *Storage, userID int, amount int) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	_, err = s.postLedgerTransaction(ctx, tx, ledgerTransaction{
+		kind:     entity.LedgerAdjustment,
+		sourceID: fmt.Sprintf("test:%d:%d", userID, time.Now().UnixNano()),
+	},
+		userPosting(userID, amount),
+		systemPosting(entity.AccountAdjustments, -amount),
+	)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func (s *Storage) GetReferralStats(ctx context.Context, userID int) (*entity.ReferralStats, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		SELECT COALESCE(u.referral_code, ''), 
+		       COUNT(r.id), 
+		       COUNT(r.id) FILTER (WHERE r.status = $2)
+		FROM users u
+		LEFT JOIN referrals r ON r.referrer_id = u.id
+		WHERE u.id = $1
+		GROUP BY u.id`
+
+	var stats entity.ReferralStats
+	err := s.db.QueryRowContext(timeoutCtx, query, userID, entity.ReferralRewarded).
+		Scan(&stats.Code, &stats.Referred, &stats.Rewarded)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// lockPendingReferral finds the user's referral that is not resolved yet, if
+// the credit carries a referral bonus, and locks it together with the
+// balances of the user and the referrer. The balances are locked in the same
+// order as a transfer locks them, so a transfer between the two users can't
+// deadlock with the credit. It returns zero ids if there is nothing to do.
+func lockPendingReferral(ctx context.Context, tx *sql.Tx, userID int, credit entity.ReferralCredit) (int, int, error) {
+	if credit.Bonus <= 0 {
+		return 0, 0, nil
+	}
+
+	referralQuery := `
+		SELECT id, referrer_id
+		FROM referrals
+		WHERE referee_id = $1
+		  AND status = $2
+		FOR UPDATE`
+
+	var referralID, referrerID int
+	err := tx.QueryRowContext(ctx, referralQuery, userID, entity.ReferralPending).Scan(&referralID, &referrerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, nil
+	}
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	lockQuery := `
+		SELECT user_id
+		FROM balances
+		WHERE user_id IN ($1, $2)
+		ORDER BY user_id
+		FOR UPDATE`
+
+	if _, err := tx.ExecContext(ctx, lockQuery, userID, referrerID); err != nil {
+		return 0, 0, err
+	}
+
+	return referralID, referrerID, nil
+}
+
+// applyReferralBonus rewards the user and their referrer within tx for the
+// referral locked by lockPendingReferral. The referrer's balance row
+// serialises the rewards of their referrals, so two referees can't both take
+// the last place under the limit. Once the referrer has been rewarded for
+// credit.Limit referrals, further referrals are closed as capped without a
+// bonus.
+func (s *Storage) applyReferralBonus(ctx context.Context, tx *sql.Tx, userID, referralID, referrerID int, credit entity.ReferralCredit) error {
+	if referralID == 0 {
+		return nil
+	}
+
+	status := entity.ReferralRewarded
+	if credit.Limit > 0 {
+		countQuery := `
+			SELECT COUNT(*)
+			FROM referrals
+			WHERE referrer_id = $1
+			  AND status = $2`
+
+		var rewarded int
+		if err := tx.QueryRowContext(ctx, countQuery, referrerID, entity.ReferralRewarded).Scan(&rewarded); err != nil {
+			return err
+		}
+
+		if rewarded >= credit.Limit {
+			status = entity.ReferralCapped
+		}
+	}
+
+	if status == entity.ReferralRewarded {
+		lt := ledgerTransaction{
+			kind:     entity.LedgerReferralBonus,
+			sourceID: strconv.Itoa(referralID),
+		}
+
+		_, err := s.postLedgerTransaction(ctx, tx, lt,
+			userPosting(userID, credit.Bonus),
+			userPosting(referrerID, credit.Bonus),
+			systemPosting(entity.AccountReferrals, -2*credit.Bonus),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	resolveQuery := `
+		UPDATE referrals
+		SET status = $1,
+		    resolved_at = CURRENT_TIMESTAMP
+		WHERE id = $2`
+
+	_, err := tx.ExecContext(ctx, resolveQuery, status, referralID)
+
+	return err
+}
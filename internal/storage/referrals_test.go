@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func TestStorage_CreditOrderAccrual_ReferralBonus(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	referrerID := createTestUser(t, s)
+
+	stats, err := s.GetReferralStats(ctx, referrerID)
+	require.NoError(t, err)
+
+	referrer, err := s.GetUserByReferralCode(ctx, stats.Code)
+	require.NoError(t, err)
+	require.Equal(t, referrerID, referrer.ID)
+
+	credit := entity.OrderCredit{Referral: entity.ReferralCredit{Bonus: 10000, Limit: 1}}
+
+	var referees []int
+	for i := 0; i < 2; i++ {
+		refereeID, err := s.SaveUser(ctx, entity.User{
+			Login:        fmt.Sprintf("referee-%d", time.Now().UnixNano()),
+			PasswordHash: "hash",
+			ReferralCode: fmt.Sprintf("R%d", time.Now().UnixNano()%1e15),
+			ReferrerID:   referrerID,
+		})
+		require.NoError(t, err)
+		require.NoError(t, s.CreateBalance(ctx, refereeID))
+
+		referees = append(referees, refereeID)
+
+		for j := 0; j < 2; j++ {
+			order := entity.Order{
+				ID:     fmt.Sprintf("%d", time.Now().UnixNano()),
+				UserID: refereeID,
+				Status: "NEW",
+			}
+			require.NoError(t, s.SaveOrder(ctx, order))
+
+			order.Status = "PROCESSED"
+			require.NoError(t, s.CreditOrderAccrual(ctx, order, credit))
+		}
+	}
+
+	balance, err := s.GetBalance(ctx, referrerID)
+	require.NoError(t, err)
+	assert.Equal(t, 10000, balance.Current)
+
+	first, err := s.GetBalance(ctx, referees[0])
+	require.NoError(t, err)
+	assert.Equal(t, 10000, first.Current)
+
+	second, err := s.GetBalance(ctx, referees[1])
+	require.NoError(t, err)
+	assert.Equal(t, 0, second.Current)
+
+	stats, err = s.GetReferralStats(ctx, referrerID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Referred)
+	assert.Equal(t, 1, stats.Rewarded)
+}
+
+func TestStorage_CreditOrderAccrual_ReferralBonusConcurrentTransfer(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	referrerID := createTestUser(t, s)
+	creditTestUser(t, s, referrerID, 1000)
+
+	credit := entity.OrderCredit{Referral: entity.ReferralCredit{Bonus: 10000}}
+
+	for i := 0; i < 10; i++ {
+		refereeID, err := s.SaveUser(ctx, entity.User{
+			Login:        fmt.Sprintf("referee-%d", time.Now().UnixNano()),
+			PasswordHash: "hash",
+			ReferralCode: fmt.Sprintf("R%d", time.Now().UnixNano()%1e15),
+			ReferrerID:   referrerID,
+		})
+		require.NoError(t, err)
+		require.NoError(t, s.CreateBalance(ctx, refereeID))
+
+		order := entity.Order{
+			ID:     fmt.Sprintf("%d", time.Now().UnixNano()),
+			UserID: refereeID,
+			Status: "NEW",
+		}
+		require.NoError(t, s.SaveOrder(ctx, order))
+
+		order.Status = "PROCESSED"
+		order.Accrual = 500
+
+		// The first credit of the referee rewards the referrer while the
+		// referrer sends points to the referee.
+		var wg sync.WaitGroup
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, s.CreditOrderAccrual(ctx, order, credit))
+		}()
+
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, s.Transfer(ctx, entity.Transfer{FromUserID: referrerID, ToUserID: refereeID, Sum: 10}, 0))
+		}()
+
+		wg.Wait()
+
+		balance, err := s.GetBalance(ctx, refereeID)
+		require.NoError(t, err)
+		assert.Equal(t, 10510, balance.Current)
+	}
+}
@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+var (
+	ErrRewardNotFound   = errors.New("reward not found")
+	ErrRewardOutOfStock = errors.New("reward is out of stock")
+)
+
+func (s *Storage) SaveReward(ctx context.Context, r entity.Reward) (*entity.Reward, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		INSERT INTO rewards 
+		    (name, 
+		     description, 
+		     price, 
+		     stock, 
+		     active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`
+
+	err := s.db.QueryRowContext(timeoutCtx, query, r.Name, r.Description, r.Price, r.Stock, r.Active).
+		Scan(&r.ID, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// UpdateReward replaces the reward's details, price and stock. Redemptions
+// already made keep the name and price they were made at.
+func (s *Storage) UpdateReward(ctx context.Context, r entity.Reward) (*entity.Reward, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		UPDATE rewards
+		SET name = $1,
+		    description = $2,
+		    price = $3,
+		    stock = $4,
+		    active = $5,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+		RETURNING created_at, updated_at`
+
+	err := s.db.QueryRowContext(timeoutCtx, query, r.Name, r.Description, r.Price, r.Stock, r.Active, r.ID).
+		Scan(&r.CreatedAt, &r.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRewardNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// GetRewards returns the rewards ordered by price, only those that can be
+// redeemed unless all is set.
+func (s *Storage) GetRewards(ctx context.Context, all bool) ([]entity.Reward, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		SELECT id, 
+		       name, 
+		       description, 
+		       price, 
+		       stock, 
+		       active, 
+		       created_at, 
+		       updated_at
+		FROM rewards
+		WHERE $1 OR (active AND stock > 0)
+		ORDER BY price, id`
+
+	rows, err := s.db.QueryContext(timeoutCtx, query, all)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var rewards []entity.Reward
+	for rows.Next() {
+		var r entity.Reward
+
+		err := rows.Scan(
+			&r.ID,
+			&r.Name,
+			&r.Description,
+			&r.Price,
+			&r.Stock,
+			&r.Active,
+			&r.CreatedAt,
+			&r.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		rewards = append(rewards, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rewards, nil
+}
+
+// Redeem takes one item of the reward out of stock and debits its price from
+// the user's balance in one transaction. The debit goes through the ledger
+// like a withdrawal does, so a balance that doesn't cover the price returns
+// ErrInsufficientFunds and leaves the stock as it was.
+func (s *Storage) Redeem(ctx context.Context, userID int, rewardID int) (*entity.Redemption, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rewardQuery := `
+		SELECT name, 
+		       price, 
+		       stock
+		FROM rewards
+		WHERE id = $1
+		  AND active
+		FOR UPDATE`
+
+	redemption := entity.Redemption{UserID: userID, RewardID: rewardID}
+
+	var stock int
+	err = tx.QueryRowContext(timeoutCtx, rewardQuery, rewardID).Scan(&redemption.RewardName, &redemption.Price, &stock)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRewardNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if stock <= 0 {
+		return nil, ErrRewardOutOfStock
+	}
+
+	stockQuery := `
+		UPDATE rewards
+		SET stock = stock - 1
+		WHERE id = $1`
+
+	if _, err := tx.ExecContext(timeoutCtx, stockQuery, rewardID); err != nil {
+		return nil, err
+	}
+
+	redemptionQuery := `
+		INSERT INTO redemptions 
+		    (user_id, 
+		     reward_id, 
+		     reward_name, 
+		     price)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, redeemed_at`
+
+	err = tx.QueryRowContext(timeoutCtx, redemptionQuery, userID, rewardID, redemption.RewardName, redemption.Price).
+		Scan(&redemption.ID, &redemption.RedeemedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	lt := ledgerTransaction{
+		kind:      entity.LedgerRedemption,
+		sourceID:  strconv.Itoa(redemption.ID),
+		reference: redemption.RewardName,
+	}
+
+	_, err = s.postLedgerTransaction(timeoutCtx, tx, lt,
+		userPosting(userID, -redemption.Price),
+		systemPosting(entity.AccountRewards, redemption.Price),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &redemption, nil
+}
+
+// GetUserRedemptions returns the user's redemptions, newest first, with the
+// names and prices the rewards had when they were redeemed.
+func (s *Storage) GetUserRedemptions(ctx context.Context, userID int) ([]entity.Redemption, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		SELECT id, 
+		       reward_id, 
+		       reward_name, 
+		       price, 
+		       redeemed_at
+		FROM redemptions
+		WHERE user_id = $1
+		ORDER BY redeemed_at DESC, id DESC`
+
+	rows, err := s.db.QueryContext(timeoutCtx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var redemptions []entity.Redemption
+	for rows.Next() {
+		redemption := entity.Redemption{UserID: userID}
+
+		err := rows.Scan(
+			&redemption.ID,
+			&redemption.RewardID,
+			&redemption.RewardName,
+			&redemption.Price,
+			&redemption.RedeemedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		redemptions = append(redemptions, redemption)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return redemptions, nil
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func TestStorage_Redeem(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+	creditTestUser(t, s, userID, 1500)
+
+	reward, err := s.SaveReward(ctx, entity.Reward{Name: "mug", Price: 1000, Stock: 2, Active: true})
+	require.NoError(t, err)
+
+	redemption, err := s.Redeem(ctx, userID, reward.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, redemption.Price)
+
+	_, err = s.Redeem(ctx, userID, reward.ID)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	rewards, err := s.GetRewards(ctx, true)
+	require.NoError(t, err)
+
+	for _, r := range rewards {
+		if r.ID == reward.ID {
+			assert.Equal(t, 1, r.Stock)
+		}
+	}
+
+	balance, err := s.GetBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 500, balance.Current)
+	assert.Equal(t, 1000, balance.Withdrawn)
+
+	redemptions, err := s.GetUserRedemptions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, redemptions, 1)
+	assert.Equal(t, "mug", redemptions[0].RewardName)
+
+	_, err = s.UpdateReward(ctx, entity.Reward{ID: reward.ID, Name: "big mug", Price: 100, Stock: 0, Active: true})
+	require.NoError(t, err)
+
+	redemptions, err = s.GetUserRedemptions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, redemptions, 1)
+	assert.Equal(t, "mug", redemptions[0].RewardName)
+	assert.Equal(t, 1000, redemptions[0].Price)
+
+	_, err = s.Redeem(ctx, userID, reward.ID)
+	assert.ErrorIs(t, err, ErrRewardOutOfStock)
+
+	_, err = s.Redeem(ctx, userID, -1)
+	assert.ErrorIs(t, err, ErrRewardNotFound)
+}
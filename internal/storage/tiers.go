@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+// GetLifetimeAccrual returns the sum of the accruals of the user's credited
+// orders.
+func (s *Storage) GetLifetimeAccrual(ctx context.Context, userID int) (int, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		SELECT COALESCE(SUM(accrual), 0)
+		FROM orders
+		WHERE user_id = $1
+		  AND credited_at IS NOT NULL`
+
+	var lifetime int
+	if err := s.db.QueryRowContext(timeoutCtx, query, userID).Scan(&lifetime); err != nil {
+		return 0, err
+	}
+
+	return lifetime, nil
+}
+
+// applyTierCredit posts the bonus the order earns under the user's tier and
+// records the tier change its accrual brings, if any, within tx. The order
+// must already be marked credited in tx. Credits of one user are serialised
+// on their balance row, which is locked before the lifetime accrual is read,
+// so every credit sees the orders credited before it and a threshold crossed
+// by two concurrent orders together is still recorded.
+func (s *Storage) applyTierCredit(ctx context.Context, tx *sql.Tx, userID int, order entity.Order, tiers []entity.Tier) error {
+	if len(tiers) == 0 {
+		return nil
+	}
+
+	lockQuery := `
+		SELECT user_id
+		FROM balances
+		WHERE user_id = $1
+		FOR UPDATE`
+
+	if _, err := tx.ExecContext(ctx, lockQuery, userID); err != nil {
+		return err
+	}
+
+	lifetimeQuery := `
+		SELECT COALESCE(SUM(accrual), 0)
+		FROM orders
+		WHERE user_id = $1
+		  AND credited_at IS NOT NULL`
+
+	var lifetime int
+	if err := tx.QueryRowContext(ctx, lifetimeQuery, userID).Scan(&lifetime); err != nil {
+		return err
+	}
+
+	before := entity.TierFor(tiers, lifetime-order.Accrual)
+	after := entity.TierFor(tiers, lifetime)
+
+	// The bonus is rounded down to whole hundredths of a point.
+	bonus := int(decimal.NewFromInt(int64(order.Accrual)).
+		Mul(before.Multiplier.Sub(decimal.NewFromInt(1))).
+		IntPart())
+
+	if bonus > 0 {
+		lt := ledgerTransaction{
+			kind:      entity.LedgerTierBonus,
+			sourceID:  order.ID,
+			reference: order.ID,
+		}
+
+		_, err := s.postLedgerTransaction(ctx, tx, lt,
+			userPosting(userID, bonus),
+			systemPosting(entity.AccountBonuses, -bonus),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if after.Name == before.Name {
+		return nil
+	}
+
+	changeQuery := `
+		INSERT INTO tier_changes 
+		    (user_id, 
+		     from_tier, 
+		     to_tier, 
+		     lifetime_accrual)
+		VALUES ($1, $2, $3, $4)`
+
+	_, err := tx.ExecContext(ctx, changeQuery, userID, before.Name, after.Name, lifetime)
+
+	return err
+}
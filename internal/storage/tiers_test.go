@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+var testTiers = []entity.Tier{
+	{Name: "BRONZE", Threshold: 0, Multiplier: decimal.NewFromInt(1)},
+	{Name: "SILVER", Threshold: 100000, Multiplier: decimal.RequireFromString("1.1")},
+}
+
+// getTestTierChanges returns the tiers the user moved to, oldest first.
+func getTestTierChanges(t *testing.T, s *Storage, userID int) []string {
+	t.Helper()
+
+	rows, err := s.db.Query(`SELECT to_tier FROM tier_changes WHERE user_id = $1 ORDER BY changed_at, id`, userID)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var tiers []string
+	for rows.Next() {
+		var tier string
+		require.NoError(t, rows.Scan(&tier))
+		tiers = append(tiers, tier)
+	}
+	require.NoError(t, rows.Err())
+
+	return tiers
+}
+
+func createTestOrder(t *testing.T, s *Storage, userID int, accrual int) entity.Order {
+	t.Helper()
+
+	order := entity.Order{
+		ID:     fmt.Sprintf("%d", time.Now().UnixNano()),
+		UserID: userID,
+		Status: "NEW",
+	}
+	require.NoError(t, s.SaveOrder(context.Background(), order))
+
+	order.Status = "PROCESSED"
+	order.Accrual = accrual
+
+	return order
+}
+
+func TestStorage_CreditOrderAccrual_TierBonus(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+	credit := entity.OrderCredit{Tiers: testTiers}
+
+	first := createTestOrder(t, s, userID, 100000)
+	second := createTestOrder(t, s, userID, 10000)
+
+	require.NoError(t, s.CreditOrderAccrual(ctx, first, credit))
+	require.NoError(t, s.CreditOrderAccrual(ctx, first, credit))
+	require.NoError(t, s.CreditOrderAccrual(ctx, second, credit))
+
+	balance, err := s.GetBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 111000, balance.Current)
+
+	lifetime, err := s.GetLifetimeAccrual(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 110000, lifetime)
+
+	assert.Equal(t, []string{"SILVER"}, getTestTierChanges(t, s, userID))
+}
+
+func TestStorage_CreditOrderAccrual_ConcurrentTierChange(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+	credit := entity.OrderCredit{Tiers: testTiers}
+
+	// Neither order reaches the threshold alone, both together do.
+	orders := []entity.Order{
+		createTestOrder(t, s, userID, 60000),
+		createTestOrder(t, s, userID, 60000),
+	}
+
+	var wg sync.WaitGroup
+	for _, order := range orders {
+		wg.Add(1)
+
+		go func(order entity.Order) {
+			defer wg.Done()
+			assert.NoError(t, s.CreditOrderAccrual(ctx, order, credit))
+		}(order)
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, []string{"SILVER"}, getTestTierChanges(t, s, userID))
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+var ErrTransferLimitExceeded = errors.New("transfer exceeds the daily limit")
+
+// Transfer moves points from one user to another in one transaction. The sum
+// of the sender's transfers over the last 24 hours must stay within
+// dailyLimit, zero means no limit.
+func (s *Storage) Transfer(ctx context.Context, t entity.Transfer, dailyLimit int) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock both balances in the same order for every transfer, so two users
+	// sending points to each other can't deadlock.
+	lockQuery := `
+		SELECT user_id
+		FROM balances
+		WHERE user_id IN ($1, $2)
+		ORDER BY user_id
+		FOR UPDATE`
+
+	if _, err := tx.ExecContext(timeoutCtx, lockQuery, t.FromUserID, t.ToUserID); err != nil {
+		return err
+	}
+
+	if dailyLimit > 0 {
+		sentQuery := `
+			SELECT COALESCE(SUM(sum), 0)
+			FROM transfers
+			WHERE from_user_id = $1
+			  AND created_at > CURRENT_TIMESTAMP - INTERVAL '1 day'`
+
+		var sent int
+		if err := tx.QueryRowContext(timeoutCtx, sentQuery, t.FromUserID).Scan(&sent); err != nil {
+			return err
+		}
+
+		if sent+t.Sum > dailyLimit {
+			return ErrTransferLimitExceeded
+		}
+	}
+
+	transferQuery := `
+		INSERT INTO transfers 
+		    (from_user_id, 
+		     to_user_id, 
+		     sum)
+		VALUES ($1, $2, $3)
+		RETURNING id`
+
+	var transferID int
+	err = tx.QueryRowContext(timeoutCtx, transferQuery, t.FromUserID, t.ToUserID, t.Sum).Scan(&transferID)
+	if err != nil {
+		return err
+	}
+
+	loginsQuery := `
+		SELECT (SELECT login FROM users WHERE id = $1), 
+		       (SELECT login FROM users WHERE id = $2)`
+
+	var fromLogin, toLogin string
+	err = tx.QueryRowContext(timeoutCtx, loginsQuery, t.FromUserID, t.ToUserID).Scan(&fromLogin, &toLogin)
+	if err != nil {
+		return err
+	}
+
+	lt := ledgerTransaction{
+		kind:     entity.LedgerTransfer,
+		sourceID: strconv.Itoa(transferID),
+	}
+
+	// Each side sees the login of the other next to the entry. The recipient
+	// takes over the sender's lots, so passing points back and forth doesn't
+	// put off their expiry.
+	debit := userPosting(t.FromUserID, -t.Sum)
+	debit.reference = toLogin
+
+	_, err = s.postLedgerTransaction(timeoutCtx, tx, lt,
+		debit,
+		movingPosting(t.ToUserID, t.Sum, fromLogin),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
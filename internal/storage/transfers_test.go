@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func TestStorage_Transfer(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	sender := createTestUser(t, s)
+	recipient := createTestUser(t, s)
+
+	creditTestUser(t, s, sender, 1000)
+
+	err := s.Transfer(ctx, entity.Transfer{FromUserID: sender, ToUserID: recipient, Sum: 400}, 500)
+	require.NoError(t, err)
+
+	err = s.Transfer(ctx, entity.Transfer{FromUserID: sender, ToUserID: recipient, Sum: 200}, 500)
+	assert.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	err = s.Transfer(ctx, entity.Transfer{FromUserID: sender, ToUserID: recipient, Sum: 700}, 0)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	senderBalance, err := s.GetBalance(ctx, sender)
+	require.NoError(t, err)
+	assert.Equal(t, 600, senderBalance.Current)
+
+	recipientBalance, err := s.GetBalance(ctx, recipient)
+	require.NoError(t, err)
+	assert.Equal(t, 400, recipientBalance.Current)
+
+	parties := map[int]int{sender: recipient, recipient: sender}
+	for userID, otherID := range parties {
+		history, err := s.GetBalanceHistory(ctx, userID, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		require.NotEmpty(t, history)
+
+		var login string
+		require.NoError(t, s.db.QueryRow(`SELECT login FROM users WHERE id = $1`, otherID).Scan(&login))
+
+		assert.Equal(t, entity.LedgerTransfer, history[len(history)-1].Kind)
+		assert.Equal(t, login, history[len(history)-1].Reference)
+	}
+}
+
+func TestStorage_Transfer_KeepsEarningTime(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	first := createTestUser(t, s)
+	second := createTestUser(t, s)
+
+	creditTestUser(t, s, first, 1000)
+
+	_, err := s.db.ExecContext(ctx, `UPDATE point_lots SET earned_at = earned_at - INTERVAL '2 hours' WHERE user_id = $1`, first)
+	require.NoError(t, err)
+
+	// Passing the points back and forth doesn't make them any younger.
+	require.NoError(t, s.Transfer(ctx, entity.Transfer{FromUserID: first, ToUserID: second, Sum: 1000}, 0))
+	require.NoError(t, s.Transfer(ctx, entity.Transfer{FromUserID: second, ToUserID: first, Sum: 600}, 0))
+
+	for userID, want := range map[int]int{first: 600, second: 400} {
+		expiring, err := s.GetExpiringPoints(ctx, userID, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, want, expiring.Amount)
+	}
+}
+
+func TestStorage_Transfer_Crosswise(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	first := createTestUser(t, s)
+	second := createTestUser(t, s)
+
+	creditTestUser(t, s, first, 1000)
+	creditTestUser(t, s, second, 1000)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, s.Transfer(ctx, entity.Transfer{FromUserID: first, ToUserID: second, Sum: 10}, 0))
+		}()
+
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, s.Transfer(ctx, entity.Transfer{FromUserID: second, ToUserID: first, Sum: 10}, 0))
+		}()
+	}
+
+	wg.Wait()
+
+	balance, err := s.GetBalance(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, balance.Current)
+}
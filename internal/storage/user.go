@@ -10,21 +10,39 @@ import (
 	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
 )
 
-var ErrAlreadyExist = errors.New("login already exist in database")
+var (
+	ErrAlreadyExist       = errors.New("login already exist in database")
+	ErrReferralCodeExists = errors.New("referral code already exist in database")
+)
+
+const referralCodeConstraint = "users_referral_code_key"
 
+// SaveUser stores the user and, if the user was referred, the referral in
+// one transaction. A referral code that is already taken returns
+// ErrReferralCodeExists, so the caller can retry with another one.
 func (s *Storage) SaveUser(ctx context.Context, user entity.User) (int, error) {
 	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
 	defer cancel()
 
+	tx, err := s.db.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO users (login, password)
-		VALUES ($1, $2)
+		INSERT INTO users (login, password, referral_code)
+		VALUES ($1, $2, NULLIF($3, ''))
 		RETURNING id`
 
 	var userID int
-	err := s.db.QueryRowContext(timeoutCtx, query, user.Login, user.PasswordHash).Scan(&userID)
+	err = tx.QueryRowContext(timeoutCtx, query, user.Login, user.PasswordHash, user.ReferralCode).Scan(&userID)
 	if err != nil {
 		if pgErr, ok := err.(*pgconn.PgError); ok {
+			if pgErr.Code == uniqueViolationErrCode && pgErr.ConstraintName == referralCodeConstraint {
+				return 0, ErrReferralCodeExists
+			}
+
 			if pgErr.Code == uniqueViolationErrCode {
 				return 0, ErrAlreadyExist
 			}
@@ -32,6 +50,20 @@ func (s *Storage) SaveUser(ctx context.Context, user entity.User) (int, error) {
 		return 0, err
 	}
 
+	if user.ReferrerID != 0 {
+		referralQuery := `
+			INSERT INTO referrals (referrer_id, referee_id)
+			VALUES ($1, $2)`
+
+		if _, err := tx.ExecContext(timeoutCtx, referralQuery, user.ReferrerID, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
 	return userID, nil
 }
 
@@ -53,3 +85,23 @@ func (s *Storage) GetUser(ctx context.Context, login string) (*entity.User, erro
 
 	return &user, nil
 }
+
+// GetUserByReferralCode returns the owner of the referral code.
+func (s *Storage) GetUserByReferralCode(ctx context.Context, code string) (*entity.User, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	query := `
+		SELECT id, login, referral_code
+		FROM users
+		WHERE referral_code = $1`
+
+	row := s.db.QueryRowContext(timeoutCtx, query, code)
+
+	var user entity.User
+	if err := row.Scan(&user.ID, &user.Login, &user.ReferralCode); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -2,29 +2,38 @@ package storage
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+
 	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
 )
 
+var (
+	ErrWithdrawalOrderExists = errors.New("withdrawal with this order number already exist")
+	ErrWithdrawalNotFound    = errors.New("withdrawal not found")
+)
+
+// Withdraw records the withdrawal and debits it from the balance through the
+// ledger in one transaction. The debit only applies while the balance covers
+// it, so concurrent withdrawals can never take the balance below zero. An
+// order number can be paid with points only once, ErrWithdrawalOrderExists is
+// returned for one already used for a withdrawal or a hold.
 func (s *Storage) Withdraw(ctx context.Context, w entity.Withdraw) error {
 	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
 	defer cancel()
 
-	tx, err := s.db.Begin()
+	tx, err := s.db.BeginTx(timeoutCtx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	balanceQuery := `
-		UPDATE balances 
-		SET current = current - $1,
-			withdrawn = withdrawn + $1
-		WHERE user_id = $2`
-
-	_, err = tx.ExecContext(timeoutCtx, balanceQuery, w.Sum, w.UserID)
-	if err != nil {
+	if err := useOrderNumber(timeoutCtx, tx, w.UserID, w.OrderID); err != nil {
 		return err
 	}
 
@@ -33,9 +42,30 @@ func (s *Storage) Withdraw(ctx context.Context, w entity.Withdraw) error {
 		    (user_id, 
 		     order_id, 
 		     sum)
-		VALUES ($1, $2, $3)`
+		VALUES ($1, $2, $3)
+		RETURNING id`
+
+	var withdrawalID int
+	err = tx.QueryRowContext(timeoutCtx, withdrawQuery, w.UserID, w.OrderID, w.Sum).Scan(&withdrawalID)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok {
+			if pgErr.Code == uniqueViolationErrCode {
+				return ErrWithdrawalOrderExists
+			}
+		}
+		return err
+	}
+
+	lt := ledgerTransaction{
+		kind:      entity.LedgerWithdrawal,
+		sourceID:  strconv.Itoa(withdrawalID),
+		reference: w.OrderID,
+	}
 
-	_, err = tx.ExecContext(timeoutCtx, withdrawQuery, w.UserID, w.OrderID, w.Sum)
+	_, err = s.postLedgerTransaction(timeoutCtx, tx, lt,
+		userPosting(w.UserID, -w.Sum),
+		systemPosting(entity.AccountWithdrawals, w.Sum),
+	)
 	if err != nil {
 		return err
 	}
@@ -43,19 +73,159 @@ func (s *Storage) Withdraw(ctx context.Context, w entity.Withdraw) error {
 	return tx.Commit()
 }
 
-func (s *Storage) GetUserWithdrawals(ctx context.Context, userID int) ([]entity.Withdraw, error) {
+// useOrderNumber marks the order number as paid with points. Withdrawals and
+// holds share the key, so a number is used by one of them only, and a
+// concurrent use of the same number waits for this transaction to finish.
+func useOrderNumber(ctx context.Context, tx *sql.Tx, userID int, orderID string) error {
+	query := `
+		INSERT INTO used_order_numbers 
+		    (order_id, 
+		     user_id)
+		VALUES ($1, $2)`
+
+	_, err := tx.ExecContext(ctx, query, orderID, userID)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok {
+			if pgErr.Code == uniqueViolationErrCode {
+				return ErrWithdrawalOrderExists
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// freeOrderNumber makes the order number available for a withdrawal or a hold
+// again.
+func freeOrderNumber(ctx context.Context, tx *sql.Tx, orderID string) error {
+	query := `
+		DELETE FROM used_order_numbers
+		WHERE order_id = $1`
+
+	_, err := tx.ExecContext(ctx, query, orderID)
+
+	return err
+}
+
+// ReverseWithdrawal returns the points of the withdrawal made for the order
+// to the user and marks the withdrawal as reversed. A withdrawal is reversed
+// once only, reversing it again changes nothing and returns the withdrawal as
+// it is.
+func (s *Storage) ReverseWithdrawal(ctx context.Context, orderID string) (*entity.Withdraw, error) {
 	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
 	defer cancel()
 
-	query := `
-		SELECT order_id, 
+	tx, err := s.db.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	selectQuery := `
+		SELECT id, 
+		       user_id, 
+		       sum, 
+		       processed_at, 
+		       reversed_at
+		FROM withdrawals
+		WHERE order_id = $1
+		FOR UPDATE`
+
+	var (
+		withdrawalID int
+		reversedAt   sql.NullTime
+	)
+
+	w := entity.Withdraw{OrderID: orderID}
+
+	err = tx.QueryRowContext(timeoutCtx, selectQuery, orderID).
+		Scan(&withdrawalID, &w.UserID, &w.Sum, &w.ProcessedAt, &reversedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWithdrawalNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if reversedAt.Valid {
+		w.ReversedAt = reversedAt.Time
+		return &w, nil
+	}
+
+	updateQuery := `
+		UPDATE withdrawals
+		SET reversed_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING reversed_at`
+
+	err = tx.QueryRowContext(timeoutCtx, updateQuery, withdrawalID).Scan(&w.ReversedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	lt := ledgerTransaction{
+		kind:      entity.LedgerReversal,
+		sourceID:  strconv.Itoa(withdrawalID),
+		reference: orderID,
+	}
+
+	withdrawal := ledgerTransaction{
+		kind:     entity.LedgerWithdrawal,
+		sourceID: strconv.Itoa(withdrawalID),
+	}
+
+	_, err = s.postLedgerTransaction(timeoutCtx, tx, lt,
+		restoringPosting(w.UserID, w.Sum, withdrawal),
+		systemPosting(entity.AccountWithdrawals, -w.Sum),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// GetUserWithdrawals returns a page of the user's withdrawals selected by
+// filter, sorted by the time they were processed.
+func (s *Storage) GetUserWithdrawals(ctx context.Context, userID int, filter entity.WithdrawalFilter) ([]entity.Withdraw, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*contextTimeoutSeconds)
+	defer cancel()
+
+	op, dir := pageOrder(filter.Page)
+	after, afterID := pageCursor(filter.Page)
+
+	query := fmt.Sprintf(`
+		SELECT id, 
+		       order_id, 
 		       sum, 
-		       processed_at
+		       processed_at, 
+		       reversed_at
 		FROM withdrawals
 		WHERE user_id = $1
-		ORDER BY processed_at ASC`
+		  AND ($2::boolean IS NULL OR (reversed_at IS NOT NULL) = $2)
+		  AND ($3::timestamp IS NULL OR processed_at >= $3)
+		  AND ($4::timestamp IS NULL OR processed_at < $4)
+		  AND ($5::timestamp IS NULL OR (processed_at, id) %[1]s ($5, $6::int))
+		ORDER BY processed_at %[2]s, id %[2]s
+		LIMIT $7`, op, dir)
 
-	rows, err := s.db.QueryContext(timeoutCtx, query, userID)
+	var reversed sql.NullBool
+	if filter.Reversed != nil {
+		reversed = sql.NullBool{Bool: *filter.Reversed, Valid: true}
+	}
+
+	if afterID == "" {
+		afterID = "0"
+	}
+
+	rows, err := s.db.QueryContext(timeoutCtx, query,
+		userID, reversed, nullTime(filter.From), nullTime(filter.To), after, afterID, pageLimit(filter.Page))
 	if err != nil {
 		return nil, err
 	}
@@ -64,13 +234,18 @@ func (s *Storage) GetUserWithdrawals(ctx context.Context, userID int) ([]entity.
 
 	var withdrawals []entity.Withdraw
 	for rows.Next() {
-		var w entity.Withdraw
+		var (
+			w          = entity.Withdraw{UserID: userID}
+			reversedAt sql.NullTime
+		)
 
-		err := rows.Scan(&w.OrderID, &w.Sum, &w.ProcessedAt)
+		err := rows.Scan(&w.ID, &w.OrderID, &w.Sum, &w.ProcessedAt, &reversedAt)
 		if err != nil {
 			return nil, err
 		}
 
+		w.ReversedAt = reversedAt.Time
+
 		withdrawals = append(withdrawals, w)
 	}
 
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PrahaTurbo/gophermart/internal/storage/entity"
+)
+
+func TestStorage_Withdraw_Concurrent(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	creditTestUser(t, s, userID, 1000)
+
+	const withdrawals = 50
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int32
+	)
+
+	for i := 0; i < withdrawals; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			err := s.Withdraw(ctx, entity.Withdraw{
+				UserID:  userID,
+				OrderID: fmt.Sprintf("%d-%d", userID, i),
+				Sum:     300,
+			})
+			if err == nil {
+				succeeded.Add(1)
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrInsufficientFunds)
+		}(i)
+	}
+
+	wg.Wait()
+
+	balance, err := s.GetBalance(ctx, userID)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(3), succeeded.Load())
+	assert.Equal(t, 100, balance.Current)
+	assert.Equal(t, 900, balance.Withdrawn)
+}
+
+func TestStorage_Withdraw_DuplicateOrder(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	firstUserID := createTestUser(t, s)
+	secondUserID := createTestUser(t, s)
+
+	creditTestUser(t, s, firstUserID, 1000)
+	creditTestUser(t, s, secondUserID, 1000)
+
+	orderID := fmt.Sprintf("%d-duplicate", firstUserID)
+
+	err := s.Withdraw(ctx, entity.Withdraw{UserID: firstUserID, OrderID: orderID, Sum: 300})
+	require.NoError(t, err)
+
+	err = s.Withdraw(ctx, entity.Withdraw{UserID: firstUserID, OrderID: orderID, Sum: 300})
+	assert.ErrorIs(t, err, ErrWithdrawalOrderExists)
+
+	err = s.Withdraw(ctx, entity.Withdraw{UserID: secondUserID, OrderID: orderID, Sum: 300})
+	assert.ErrorIs(t, err, ErrWithdrawalOrderExists)
+
+	balance, err := s.GetBalance(ctx, secondUserID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1000, balance.Current)
+	assert.Equal(t, 0, balance.Withdrawn)
+}
+
+func TestStorage_ReverseWithdrawal(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, s)
+
+	creditTestUser(t, s, userID, 1000)
+
+	orderID := fmt.Sprintf("%d-reverse", userID)
+
+	err := s.Withdraw(ctx, entity.Withdraw{UserID: userID, OrderID: orderID, Sum: 300})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		w, err := s.ReverseWithdrawal(ctx, orderID)
+		require.NoError(t, err)
+
+		assert.Equal(t, userID, w.UserID)
+		assert.Equal(t, 300, w.Sum)
+		assert.False(t, w.ReversedAt.IsZero())
+	}
+
+	balance, err := s.GetBalance(ctx, userID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1000, balance.Current)
+	assert.Equal(t, 0, balance.Withdrawn)
+
+	withdrawals, err := s.GetUserWithdrawals(ctx, userID, entity.WithdrawalFilter{Page: entity.Page{Limit: 100}})
+	require.NoError(t, err)
+	require.Len(t, withdrawals, 1)
+
+	assert.False(t, withdrawals[0].ReversedAt.IsZero())
+
+	_, err = s.ReverseWithdrawal(ctx, fmt.Sprintf("%d-missing", userID))
+	assert.ErrorIs(t, err, ErrWithdrawalNotFound)
+}
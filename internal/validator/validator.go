@@ -0,0 +1,102 @@
+package validator
+
+import "strings"
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors lists every field of a request that failed validation.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Has reports whether field failed validation.
+func (e Errors) Has(field string) bool {
+	for _, fe := range e {
+		if fe.Field == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Validator struct {
+	errors Errors
+}
+
+func New() *Validator {
+	return &Validator{}
+}
+
+// Check records message for field unless ok holds. Only the first failed
+// check is kept for each field.
+func (v *Validator) Check(ok bool, field, message string) {
+	if ok || v.errors.Has(field) {
+		return
+	}
+
+	v.errors = append(v.errors, FieldError{Field: field, Message: message})
+}
+
+// Err returns the collected Errors, or nil if every check passed.
+func (v *Validator) Err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+
+	return v.errors
+}
+
+func NotBlank(s string) bool {
+	return strings.TrimSpace(s) != ""
+}
+
+func Digits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Luhn reports whether s is a non-empty string of digits with a valid Luhn
+// checksum.
+func Luhn(s string) bool {
+	if !Digits(s) {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := int(s[i] - '0')
+
+		if double {
+			c = c * 2
+			if c > 9 {
+				c = c - 9
+			}
+		}
+		double = !double
+
+		sum += c
+	}
+
+	return sum%10 == 0
+}
@@ -0,0 +1,79 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLuhn(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{
+			name:  "should accept valid number",
+			value: "12345678903",
+			want:  true,
+		},
+		{
+			name:  "should accept another valid number",
+			value: "49927398716",
+			want:  true,
+		},
+		{
+			name:  "should reject number with wrong checksum",
+			value: "1234567890",
+			want:  false,
+		},
+		{
+			name:  "should reject empty string",
+			value: "",
+			want:  false,
+		},
+		{
+			name:  "should reject non-digit characters",
+			value: "1234567890a",
+			want:  false,
+		},
+		{
+			name:  "should reject characters that pass checksum as bytes",
+			value: "0:",
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Luhn(tt.value))
+		})
+	}
+}
+
+func TestValidator(t *testing.T) {
+	t.Run("should return nil if every check passed", func(t *testing.T) {
+		v := New()
+		v.Check(true, "login", "must not be empty")
+
+		assert.NoError(t, v.Err())
+	})
+
+	t.Run("should keep first error of each field", func(t *testing.T) {
+		v := New()
+		v.Check(false, "sum", "must be greater than zero")
+		v.Check(false, "sum", "must not be empty")
+		v.Check(false, "order", "must be a valid order number")
+
+		err := v.Err()
+
+		var errs Errors
+		assert.ErrorAs(t, err, &errs)
+		assert.Equal(t, Errors{
+			{Field: "sum", Message: "must be greater than zero"},
+			{Field: "order", Message: "must be a valid order number"},
+		}, errs)
+		assert.True(t, errs.Has("order"))
+		assert.False(t, errs.Has("login"))
+	})
+}
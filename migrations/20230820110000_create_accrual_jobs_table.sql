@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS accrual_jobs (
+    order_id VARCHAR(255) PRIMARY KEY,
+    user_id INT,
+    attempts INT DEFAULT 0,
+    run_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    done_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS accrual_jobs_pending_idx ON accrual_jobs (run_at) WHERE done_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE accrual_jobs;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Concurrent withdrawals could take a balance below zero before the check
+-- was made inside the withdrawal transaction. Such balances are set to zero
+-- so the constraint can be added; the deficits are kept for support to
+-- settle with the users.
+CREATE TABLE IF NOT EXISTS balances_deficits (
+    user_id INT,
+    deficit INT,
+    moved_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+WITH cleared AS (
+    UPDATE balances b
+    SET current = 0
+    FROM (
+        SELECT user_id, current
+        FROM balances
+        WHERE current < 0
+        FOR UPDATE
+    ) n
+    WHERE b.user_id = n.user_id
+    RETURNING b.user_id, -n.current AS deficit
+)
+INSERT INTO balances_deficits (user_id, deficit)
+SELECT user_id, deficit
+FROM cleared;
+
+ALTER TABLE balances ADD CONSTRAINT balances_current_non_negative CHECK (current >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE balances DROP CONSTRAINT balances_current_non_negative;
+
+UPDATE balances b
+SET current = b.current - d.deficit
+FROM balances_deficits d
+WHERE b.user_id = d.user_id;
+
+DROP TABLE balances_deficits;
+-- +goose StatementEnd
@@ -0,0 +1,79 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS id SERIAL PRIMARY KEY;
+
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL,
+    source_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kind, source_id)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL REFERENCES ledger_transactions (id),
+    account VARCHAR(20) NOT NULL,
+    user_id INT,
+    amount INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_user_idx ON ledger_entries (user_id, account, created_at);
+
+-- Backfill the ledger from the history we already have: credited orders,
+-- withdrawals and, if the counters still disagree, an opening adjustment.
+WITH tx AS (
+    INSERT INTO ledger_transactions (kind, source_id, created_at)
+    SELECT 'ACCRUAL', order_id, credited_at
+    FROM orders
+    WHERE credited_at IS NOT NULL
+      AND accrual > 0
+    RETURNING id, source_id, created_at
+)
+INSERT INTO ledger_entries (transaction_id, account, user_id, amount, created_at)
+SELECT tx.id, 'USER', o.user_id, o.accrual, tx.created_at
+FROM tx JOIN orders o ON o.order_id = tx.source_id
+UNION ALL
+SELECT tx.id, 'ACCRUALS', NULL, -o.accrual, tx.created_at
+FROM tx JOIN orders o ON o.order_id = tx.source_id;
+
+WITH tx AS (
+    INSERT INTO ledger_transactions (kind, source_id, created_at)
+    SELECT 'WITHDRAWAL', id::text, processed_at
+    FROM withdrawals
+    RETURNING id, source_id, created_at
+)
+INSERT INTO ledger_entries (transaction_id, account, user_id, amount, created_at)
+SELECT tx.id, 'USER', w.user_id, -w.sum, tx.created_at
+FROM tx JOIN withdrawals w ON w.id::text = tx.source_id
+UNION ALL
+SELECT tx.id, 'WITHDRAWALS', NULL, w.sum, tx.created_at
+FROM tx JOIN withdrawals w ON w.id::text = tx.source_id;
+
+WITH diff AS (
+    SELECT b.user_id, b.current - COALESCE(SUM(e.amount), 0) AS amount
+    FROM balances b
+    LEFT JOIN ledger_entries e ON e.user_id = b.user_id AND e.account = 'USER'
+    GROUP BY b.user_id, b.current
+    HAVING b.current - COALESCE(SUM(e.amount), 0) <> 0
+), tx AS (
+    INSERT INTO ledger_transactions (kind, source_id)
+    SELECT 'ADJUSTMENT', 'opening:' || user_id
+    FROM diff
+    RETURNING id, source_id
+)
+INSERT INTO ledger_entries (transaction_id, account, user_id, amount)
+SELECT tx.id, 'USER', diff.user_id, diff.amount
+FROM tx JOIN diff ON tx.source_id = 'opening:' || diff.user_id
+UNION ALL
+SELECT tx.id, 'ADJUSTMENTS', NULL, -diff.amount
+FROM tx JOIN diff ON tx.source_id = 'opening:' || diff.user_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE ledger_entries;
+DROP TABLE ledger_transactions;
+ALTER TABLE withdrawals DROP COLUMN id;
+-- +goose StatementEnd
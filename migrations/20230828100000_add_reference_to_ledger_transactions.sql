@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE ledger_transactions ADD COLUMN IF NOT EXISTS reference VARCHAR(255);
+
+UPDATE ledger_transactions
+SET reference = source_id
+WHERE kind = 'ACCRUAL';
+
+UPDATE ledger_transactions t
+SET reference = w.order_id
+FROM withdrawals w
+WHERE t.kind = 'WITHDRAWAL'
+  AND t.source_id = w.id::text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE ledger_transactions DROP COLUMN reference;
+-- +goose StatementEnd
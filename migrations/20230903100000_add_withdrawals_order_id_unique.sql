@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Until now an order number could be paid for any number of times. The
+-- earliest withdrawal of every order number stays, the later ones are moved
+-- aside so the constraint can be added. Their points stay withdrawn in the
+-- ledger; the moved rows are kept for support to settle with the users.
+CREATE TABLE IF NOT EXISTS withdrawals_duplicates (
+    id INT PRIMARY KEY,
+    user_id INT,
+    order_id VARCHAR(255),
+    sum INT,
+    processed_at TIMESTAMP,
+    moved_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+WITH ranked AS (
+    SELECT id,
+           ROW_NUMBER() OVER (PARTITION BY order_id ORDER BY processed_at, id) AS n
+    FROM withdrawals
+    WHERE order_id IS NOT NULL
+), moved AS (
+    DELETE FROM withdrawals w
+    USING ranked r
+    WHERE w.id = r.id
+      AND r.n > 1
+    RETURNING w.id, w.user_id, w.order_id, w.sum, w.processed_at
+)
+INSERT INTO withdrawals_duplicates (id, user_id, order_id, sum, processed_at)
+SELECT id, user_id, order_id, sum, processed_at
+FROM moved;
+
+ALTER TABLE withdrawals ADD CONSTRAINT withdrawals_order_id_key UNIQUE (order_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE withdrawals DROP CONSTRAINT withdrawals_order_id_key;
+
+INSERT INTO withdrawals (id, user_id, order_id, sum, processed_at)
+SELECT id, user_id, order_id, sum, processed_at
+FROM withdrawals_duplicates;
+
+DROP TABLE withdrawals_duplicates;
+-- +goose StatementEnd
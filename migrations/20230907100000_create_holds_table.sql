@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS reserved INT NOT NULL DEFAULT 0;
+ALTER TABLE balances ADD CONSTRAINT balances_reserved_non_negative CHECK (reserved >= 0);
+
+CREATE TABLE IF NOT EXISTS holds (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    order_id VARCHAR(255) NOT NULL UNIQUE,
+    sum INT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS holds_expires_at_idx ON holds (expires_at) WHERE status = 'HELD';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE holds;
+
+ALTER TABLE balances DROP CONSTRAINT balances_reserved_non_negative;
+ALTER TABLE balances DROP COLUMN reserved;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS point_lots (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    transaction_id BIGINT REFERENCES ledger_transactions (id),
+    amount INT NOT NULL,
+    remaining INT NOT NULL CHECK (remaining >= 0),
+    earned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expired_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS point_lots_unspent_idx ON point_lots (user_id, earned_at, id) WHERE remaining > 0;
+CREATE INDEX IF NOT EXISTS point_lots_earned_at_idx ON point_lots (earned_at) WHERE remaining > 0;
+
+-- Points earned before lots were tracked count as earned now, so nobody
+-- loses them before a full expiry period has passed.
+INSERT INTO point_lots (user_id, amount, remaining)
+SELECT user_id, current, current
+FROM balances
+WHERE current > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE point_lots;
+-- +goose StatementEnd
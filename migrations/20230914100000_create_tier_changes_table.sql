@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS tier_changes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    from_tier VARCHAR(50) NOT NULL,
+    to_tier VARCHAR(50) NOT NULL,
+    lifetime_accrual INT NOT NULL,
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS tier_changes_user_idx ON tier_changes (user_id, changed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE tier_changes;
+-- +goose StatementEnd
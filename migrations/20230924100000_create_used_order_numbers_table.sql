@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- An order number can be paid with points once, either by a withdrawal or by
+-- a hold. Both insert the number here, so the key covers both tables.
+CREATE TABLE IF NOT EXISTS used_order_numbers (
+    order_id VARCHAR(255) PRIMARY KEY,
+    user_id INT NOT NULL,
+    used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO used_order_numbers (order_id, user_id, used_at)
+SELECT order_id, user_id, processed_at
+FROM withdrawals
+WHERE order_id IS NOT NULL
+UNION ALL
+SELECT order_id, user_id, created_at
+FROM holds
+ON CONFLICT (order_id) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE used_order_numbers;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Which lots every debit of a user account took its points from, so points
+-- given back by a hold release or a withdrawal reversal return to the lots
+-- they came from and keep their earning time. Debits posted before this table
+-- existed have no spends; points they give back start a new lot.
+CREATE TABLE IF NOT EXISTS point_lot_spends (
+    transaction_id BIGINT NOT NULL REFERENCES ledger_transactions (id),
+    lot_id BIGINT NOT NULL REFERENCES point_lots (id),
+    amount INT NOT NULL CHECK (amount > 0),
+    PRIMARY KEY (transaction_id, lot_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE point_lot_spends;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The parties of a transfer see each other's login next to the entry, so the
+-- reference of an entry overrides the one of its transaction.
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS reference VARCHAR(255);
+
+UPDATE ledger_entries e
+SET reference = u.login
+FROM ledger_transactions t, transfers tr, users u
+WHERE t.id = e.transaction_id
+  AND t.kind = 'TRANSFER'
+  AND tr.id::text = t.source_id
+  AND e.account = 'USER'
+  AND u.id = CASE WHEN e.user_id = tr.from_user_id THEN tr.to_user_id ELSE tr.from_user_id END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE ledger_entries DROP COLUMN reference;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A released or expired hold no longer uses its order number, so a checkout
+-- can hold points for the same order again. Only one hold of an order can be
+-- active or captured.
+ALTER TABLE holds DROP CONSTRAINT holds_order_id_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS holds_order_id_used_idx ON holds (order_id) WHERE status IN ('HELD', 'CAPTURED');
+CREATE INDEX IF NOT EXISTS holds_order_id_idx ON holds (user_id, order_id);
+
+DELETE FROM used_order_numbers u
+USING holds h
+WHERE h.order_id = u.order_id
+  AND h.status IN ('RELEASED', 'EXPIRED')
+  AND NOT EXISTS (SELECT 1 FROM withdrawals w WHERE w.order_id = u.order_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+INSERT INTO used_order_numbers (order_id, user_id, used_at)
+SELECT order_id, user_id, created_at
+FROM holds
+ON CONFLICT (order_id) DO NOTHING;
+
+-- Only the latest hold of every order number is kept.
+DELETE FROM holds h
+USING holds later
+WHERE later.order_id = h.order_id
+  AND later.id > h.id;
+
+DROP INDEX holds_order_id_idx;
+DROP INDEX holds_order_id_used_idx;
+
+ALTER TABLE holds ADD CONSTRAINT holds_order_id_key UNIQUE (order_id);
+-- +goose StatementEnd
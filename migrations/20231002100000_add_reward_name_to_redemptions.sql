@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A redemption keeps the name the reward had when it was redeemed, like it
+-- keeps the price. Past redemptions get the current name.
+ALTER TABLE redemptions ADD COLUMN IF NOT EXISTS reward_name VARCHAR(255);
+
+UPDATE redemptions d
+SET reward_name = r.name
+FROM rewards r
+WHERE r.id = d.reward_id;
+
+ALTER TABLE redemptions ALTER COLUMN reward_name SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE redemptions DROP COLUMN reward_name;
+-- +goose StatementEnd